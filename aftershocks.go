@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"  // Needed for the group summary
+	"math" // Needed to find the largest aftershock
+	"sort" // Needed to put the mainshocks in order
+	"time" // Needed for the time window
+)
+
+const (
+	// Smallest quake that gets its aftershocks grouped under it
+	MAINSHOCKMAG = 5.5
+
+	// How far from a mainshock in km, and how long after it, a quake is counted as an aftershock by default
+	AFTERSHOCKRADIUS = 100
+	AFTERSHOCKWINDOW = 7 * 24 * time.Hour
+)
+
+// A mainshock's aftershocks, shown as one row until it's expanded
+type quakeGroup struct {
+	members int
+	largest float64 // Largest aftershock magnitude, -Inf if none of them have one
+}
+
+// Group aftershocks under their mainshocks
+// Mainshocks are taken largest first, and each takes every quake within radius km that happened
+// within window after it and isn't already in a bigger sequence, including smaller mainshocks
+// Returns the mainshock ID for each aftershock, and the group for each mainshock with aftershocks
+func clusterAftershocks(rows []quakeRow, radius float64, window time.Duration) (map[string]string, map[string]*quakeGroup) {
+	var mainshocks []quakeRow
+	for _, row := range rows {
+		if mag, ok := quakeMagnitude(row.quake); ok && mag >= MAINSHOCKMAG {
+			mainshocks = append(mainshocks, row)
+		}
+	}
+	sort.SliceStable(mainshocks, func(i, j int) bool {
+		a, b := sortableMagnitude(mainshocks[i].quake), sortableMagnitude(mainshocks[j].quake)
+		if a != b {
+			return a > b
+		}
+		return mainshocks[i].quake.Properties.Time < mainshocks[j].quake.Properties.Time
+	})
+
+	members := make(map[string]string)
+	groups := make(map[string]*quakeGroup)
+	for _, mainshock := range mainshocks {
+		if _, ok := members[mainshock.quake.ID]; ok {
+			continue
+		}
+		center, ok := quakePoint(mainshock.quake)
+		if !ok {
+			continue
+		}
+		start := fromMillis(mainshock.quake.Properties.Time)
+
+		group := &quakeGroup{largest: math.Inf(-1)}
+		for _, row := range rows {
+			id := row.quake.ID
+			if id == mainshock.quake.ID || groups[id] != nil {
+				continue
+			}
+			if _, ok := members[id]; ok {
+				continue
+			}
+
+			after := fromMillis(row.quake.Properties.Time).Sub(start)
+			point, ok := quakePoint(row.quake)
+			if !ok || after < 0 || after > window || haversine(center, point) > radius {
+				continue
+			}
+
+			members[id] = mainshock.quake.ID
+			group.members++
+			if mag, ok := quakeMagnitude(row.quake); ok && mag > group.largest {
+				group.largest = mag
+			}
+		}
+		if group.members > 0 {
+			groups[mainshock.quake.ID] = group
+		}
+	}
+
+	return members, groups
+}
+
+// Get the text added to a mainshock's place, eg: " + 46 aftershocks, largest M5.4"
+func (g quakeGroup) summary() string {
+	text := fmt.Sprintf(" + %d aftershock", g.members)
+	if g.members != 1 {
+		text += "s"
+	}
+	if !math.IsInf(g.largest, -1) {
+		text += fmt.Sprintf(", largest M%.1f", g.largest)
+	}
+
+	return text
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// Make a row for a quake kmNorth of Ridgecrest, after hours since the sequence started
+func sequenceRow(id string, mag float64, kmNorth, after float64) quakeRow {
+	start := millis(time.Date(2019, 7, 4, 17, 33, 0, 0, time.UTC))
+	quake := testQuake(id, mag, start+int64(after*float64(time.Hour/time.Millisecond)))
+	quake.Geometry.Coordinates = []float64{-117.5, 35.7 + kmNorth/111.2, 10}
+
+	return quakeRow{quake: quake, cells: formatRow(quake)}
+}
+
+// A synthetic sequence like Ridgecrest: an M6.4 foreshock, the M7.1 a day and a half later,
+// their aftershocks, and quakes that shouldn't be grouped
+func ridgecrest() []quakeRow {
+	return []quakeRow{
+		sequenceRow("fore", 6.4, 0, 0),
+		sequenceRow("fore1", 3.1, 5, 2),       // After the foreshock, before the mainshock
+		sequenceRow("main", 7.1, 10, 34),      // Bigger, so it has first pick
+		sequenceRow("after1", 5.4, 15, 35),    // Too small to be a mainshock itself
+		sequenceRow("after2", 4.2, 90, 40),    // Near the edge of the radius
+		sequenceRow("after3", 3.3, 0, 24*6),   // Near the foreshock, but the mainshock is bigger
+		sequenceRow("far", 4.5, 150, 36),      // Outside the radius
+		sequenceRow("later", 4, 12, 24*9),     // Past the window for both
+		sequenceRow("before", 3, 0, -1),       // Before everything
+		sequenceRow("lone", 5.8, 1000, 36),    // A mainshock with nothing around it
+		sequenceRow("lone1", 2.5, 1000, 24*9), // Too late for the lone mainshock
+	}
+}
+
+func TestClusterAftershocks(t *testing.T) {
+	members, groups := clusterAftershocks(ridgecrest(), AFTERSHOCKRADIUS, AFTERSHOCKWINDOW)
+
+	want := map[string]string{
+		"after1": "main",
+		"after2": "main",
+		"after3": "main",
+		"fore1":  "fore",
+	}
+	for id, mainshock := range want {
+		if got := members[id]; got != mainshock {
+			t.Errorf("%s is under %q, want %q", id, got, mainshock)
+		}
+	}
+	for id, mainshock := range members {
+		if _, ok := want[id]; !ok {
+			t.Errorf("%s is under %q, want it on its own", id, mainshock)
+		}
+	}
+
+	var mainshocks []string
+	for id := range groups {
+		mainshocks = append(mainshocks, id)
+	}
+	sort.Strings(mainshocks)
+	if want := []string{"fore", "main"}; !equalStrings(mainshocks, want) {
+		t.Fatalf("groups = %v, want %v", mainshocks, want)
+	}
+	if got := *groups["main"]; got.members != 3 || got.largest != 5.4 {
+		t.Errorf("main group = %+v, want 3 aftershocks, largest M5.4", got)
+	}
+	if got := *groups["fore"]; got.members != 1 || got.largest != 3.1 {
+		t.Errorf("foreshock group = %+v, want 1 aftershock, largest M3.1", got)
+	}
+}
+
+func TestClusterAftershocksSmallerMainshock(t *testing.T) {
+	// An M5.6 after an M6.8 is one of its aftershocks, along with everything after it
+	rows := []quakeRow{
+		sequenceRow("big", 6.8, 0, 0),
+		sequenceRow("smaller", 5.6, 20, 10),
+		sequenceRow("after", 3, 25, 11),
+	}
+	members, groups := clusterAftershocks(rows, AFTERSHOCKRADIUS, AFTERSHOCKWINDOW)
+
+	if members["smaller"] != "big" || members["after"] != "big" {
+		t.Errorf("members = %v, want both under big", members)
+	}
+	if groups["smaller"] != nil {
+		t.Error("the smaller mainshock has a group of its own")
+	}
+}
+
+func TestQuakeGroupSummary(t *testing.T) {
+	tests := []struct {
+		group quakeGroup
+		want  string
+	}{
+		{quakeGroup{members: 46, largest: 5.4}, " + 46 aftershocks, largest M5.4"},
+		{quakeGroup{members: 1, largest: 3.1}, " + 1 aftershock, largest M3.1"},
+		{quakeGroup{members: 2, largest: math.Inf(-1)}, " + 2 aftershocks"}, // None have a magnitude
+	}
+
+	for _, test := range tests {
+		if got := test.group.summary(); got != test.want {
+			t.Errorf("summary = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestToggleGroup(t *testing.T) {
+	q := newQuakeTable(tview.NewTable(), []int{columnMagnitude, columnLocation}, colorScale{}, nil, false)
+	q.groupRadius, q.groupWindow = AFTERSHOCKRADIUS, AFTERSHOCKWINDOW
+	q.restore([]quakeRow{
+		sequenceRow("main", 7.1, 0, 0),
+		sequenceRow("after1", 4, 10, 1),
+		sequenceRow("after2", 3, 20, 2),
+		sequenceRow("other", 3, 1000, 3),
+	})
+	q.toggleGrouping()
+
+	q.selectID("main")
+	if got, want := rowIDs(q.shown), []string{"other", "main"}; !equalStrings(got, want) {
+		t.Fatalf("collapsed = %v, want %v", got, want)
+	}
+	if got := q.table.GetCell(2, 1).Text; !strings.Contains(got, "▶") || !strings.Contains(got, "+ 2 aftershocks, largest M4.0") {
+		t.Errorf("mainshock place = %q, want the collapsed summary", got)
+	}
+
+	if !q.toggleGroup() {
+		t.Fatal("the mainshock didn't expand")
+	}
+	if got, want := rowIDs(q.shown), []string{"other", "main", "after2", "after1"}; !equalStrings(got, want) {
+		t.Errorf("expanded = %v, want %v", got, want)
+	}
+	if got := q.selectedID(); got != "main" {
+		t.Errorf("selected %q after expanding, want main", got)
+	}
+
+	q.selectID("other")
+	if q.toggleGroup() {
+		t.Error("a quake without aftershocks expanded")
+	}
+
+	// Turning grouping off shows every quake in time order again
+	q.toggleGrouping()
+	if got, want := rowIDs(q.shown), []string{"other", "after2", "after1", "main"}; !equalStrings(got, want) {
+		t.Errorf("ungrouped = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"database/sql"  // Needed to talk to the archive
+	"encoding/json" // Needed to store the raw quakes
+	"flag"          // Needed to parse the query options
+	"fmt"           // Needed for printing
+	"io"            // Needed to write the query results
+	"os"            // Needed to check the archive exists
+	"strings"       // Needed to escape the archive path
+	"sync"          // Needed to stop archiving once the archive is closed
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	_ "modernc.org/sqlite" // Pure Go SQLite, so we don't need cgo
+)
+
+// How many quakes can be waiting to be written before archiving makes the fetch wait
+const ARCHIVEBUFFER = 1024
+
+// The statements that bring an archive up to each schema version, the archive's
+// user_version says how many have been applied
+// Only ever add to the end of this, archives made by older versions are migrated forward
+var archiveMigrations = []string{
+	// 1: Every revision of every quake we see
+	`CREATE TABLE quakes (
+		id      TEXT    NOT NULL,
+		time    INTEGER NOT NULL,
+		updated INTEGER NOT NULL,
+		mag     REAL,
+		place   TEXT    NOT NULL,
+		lat     REAL,
+		lon     REAL,
+		depth   REAL,
+		alert   TEXT    NOT NULL,
+		tsunami INTEGER NOT NULL,
+		status  TEXT    NOT NULL,
+		raw     TEXT    NOT NULL,
+		PRIMARY KEY (id, updated)
+	);
+	CREATE INDEX quakes_time ON quakes (time);
+	CREATE INDEX quakes_mag ON quakes (mag);`,
+}
+
+// Saves every revision of every quake we see to a SQLite database for querying later
+// Quakes are written by a single goroutine so the fetches never wait on the disk, and it's safe
+// to archive from more than one goroutine
+type quakeArchive struct {
+	db     *sql.DB
+	mu     sync.Mutex
+	closed bool
+	quakes chan usgs.Feature
+	done   chan struct{}
+	err    error // The first write error, reported when the archive is closed
+}
+
+// Open an archive, creating it or migrating it to the current schema if needed
+func openArchive(path string) (*quakeArchive, error) {
+	db, err := openArchiveDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &quakeArchive{
+		db:     db,
+		quakes: make(chan usgs.Feature, ARCHIVEBUFFER),
+		done:   make(chan struct{}),
+	}
+	go a.write()
+
+	return a, nil
+}
+
+// Open the archive database and bring its schema up to date
+func openArchiveDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows one writer, so there's no point in more connections
+	db.SetMaxOpenConns(1)
+
+	if err := migrateArchive(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Open an archive read only for the query subcommand, it's never created or migrated so querying
+// an archive a newer -archive is still writing to can't change it
+func openArchiveReadOnly(path string) (*sql.DB, error) {
+	// Escaped since the path goes in a URI, where ? and # would start the options
+	uri := "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path) + "?mode=ro"
+	db, err := sql.Open("sqlite", uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, err
+	}
+	switch {
+	case version == 0:
+		db.Close()
+		return nil, fmt.Errorf("%s isn't an archive made with -archive", path)
+	case version > len(archiveMigrations):
+		db.Close()
+		return nil, fmt.Errorf("archive schema version %d is newer than this version of QuakeCLI understands (%d)", version, len(archiveMigrations))
+	}
+
+	return db, nil
+}
+
+// Apply any migrations the archive hasn't had yet, each in its own transaction
+func migrateArchive(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(archiveMigrations) {
+		return fmt.Errorf("archive schema version %d is newer than this version of QuakeCLI understands (%d)", version, len(archiveMigrations))
+	}
+
+	for ; version < len(archiveMigrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(archiveMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating archive to version %d: %w", version+1, err)
+		}
+		// PRAGMA doesn't take parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Archive a quake that's new or was updated
+func (a *quakeArchive) save(quake usgs.Feature) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.closed {
+		a.quakes <- quake
+	}
+}
+
+// Write quakes as they come in until the archive is closed
+// Whatever has piled up is written in one transaction, so a whole feed doesn't take a sync per quake
+func (a *quakeArchive) write() {
+	defer close(a.done)
+
+	for quake := range a.quakes {
+		batch := []usgs.Feature{quake}
+		for len(a.quakes) > 0 {
+			batch = append(batch, <-a.quakes)
+		}
+
+		if err := a.insert(batch); err != nil && a.err == nil {
+			a.err = err
+		}
+	}
+}
+
+// Upsert a batch of quakes, a revision we already have is replaced with what we saw last
+func (a *quakeArchive) insert(quakes []usgs.Feature) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO quakes (id, time, updated, mag, place, lat, lon, depth, alert, tsunami, status, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id, updated) DO UPDATE SET
+			time = excluded.time, mag = excluded.mag, place = excluded.place, lat = excluded.lat,
+			lon = excluded.lon, depth = excluded.depth, alert = excluded.alert,
+			tsunami = excluded.tsunami, status = excluded.status, raw = excluded.raw`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, quake := range quakes {
+		raw, err := json.Marshal(quake)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		exported := exportQuake(quake)
+		_, err = stmt.Exec(quake.ID, quake.Properties.Time, quake.Properties.Updated, exported.Magnitude,
+			quake.Properties.Place, exported.Latitude, exported.Longitude, exported.Depth,
+			quake.Properties.Alert, quake.Properties.Tsunami, quake.Properties.Status, string(raw))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Write anything still waiting and close the archive, returning the first error we hit while archiving
+func (a *quakeArchive) Close() error {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.quakes)
+	}
+	a.mu.Unlock()
+	<-a.done
+
+	err := a.err
+	if closeErr := a.db.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Run the query subcommand, printing the latest revision of each archived quake that matches
+// as tab separated lines, oldest first, the same as -plain does
+// Returns the exit code
+func runArchiveQuery(args []string, w, errs io.Writer) int {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(errs)
+	path := flags.String("db", "", "Archive to query, made with -archive")
+	minMag := flags.Float64("min-mag", 0, "Only show quakes at or above this magnitude")
+	since := flags.String("since", "", "Only show quakes since this date or RFC3339 time")
+	until := flags.String("until", "", "Only show quakes before this date or RFC3339 time")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *path == "" {
+		fmt.Fprintln(errs, "invalid query: -db is needed")
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(errs, "invalid query: unexpected %q\n", flags.Arg(0))
+		return 2
+	}
+
+	query := `SELECT raw FROM quakes AS q
+		WHERE updated = (SELECT MAX(updated) FROM quakes WHERE id = q.id)`
+	var params []interface{}
+	if *minMag > 0 {
+		query += " AND mag >= ?"
+		params = append(params, *minMag)
+	}
+	for _, bound := range []struct {
+		value string
+		op    string
+	}{{*since, ">="}, {*until, "<"}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := parseQueryTime(bound.value)
+		if err != nil {
+			fmt.Fprintln(errs, err)
+			return 2
+		}
+		query += " AND time " + bound.op + " ?"
+		params = append(params, t.UnixNano()/int64(1e6))
+	}
+	query += " ORDER BY time, id"
+
+	// Checked first since SQLite only says it couldn't open the file
+	if _, err := os.Stat(*path); err != nil {
+		fmt.Fprintln(errs, "couldn't open archive:", err)
+		return 1
+	}
+
+	db, err := openArchiveReadOnly(*path)
+	if err != nil {
+		fmt.Fprintln(errs, "couldn't open archive:", err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := printArchived(db, w, query, params); err != nil {
+		fmt.Fprintln(errs, "query failed:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// Print each quake a query finds
+func printArchived(db *sql.DB, w io.Writer, query string, params []interface{}) error {
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return err
+		}
+
+		var quake usgs.Feature
+		if err := json.Unmarshal([]byte(raw), &quake); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, formatPlain(quake)); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
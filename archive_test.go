@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Make an archive in dir with some quakes in it, a's magnitude was revised
+func testArchive(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "quakes.db")
+	archive, err := openArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := millis(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC))
+	revised := testQuake("a", 4.8, day+1000)
+	revised.Properties.Updated = day + 5000
+	for _, quake := range []usgs.Feature{testQuake("a", 4.5, day+1000), testQuake("b", 2, day+2000), revised, testQuake("c", 5.1, day+86400000)} {
+		archive.save(quake)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestArchiveQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := testArchive(t, dir)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string // IDs, oldest first
+	}{
+		{"everything", nil, []string{"a", "b", "c"}},
+		{"min mag", []string{"-min-mag", "4.6"}, []string{"a", "c"}}, // The latest revision counts
+		{"since", []string{"-since", "2021-03-02"}, []string{"c"}},
+		{"until", []string{"-until", "2021-03-02"}, []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out, errs bytes.Buffer
+			if code := runArchiveQuery(append([]string{"-db", path}, test.args...), &out, &errs); code != 0 {
+				t.Fatalf("exit code %d: %s", code, errs.String())
+			}
+
+			var ids []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line != "" {
+					ids = append(ids, strings.Split(line, "\t")[0])
+				}
+			}
+			if !equalStrings(ids, test.want) {
+				t.Errorf("quakes = %v, want %v\n%s", ids, test.want, out.String())
+			}
+		})
+	}
+}
+
+func TestArchiveQueryIsReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Some other SQLite database, which the query mustn't turn into an archive
+	other := filepath.Join(dir, "other.db")
+	db, err := sql.Open("sqlite", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE notes (text TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	tests := []struct {
+		name string
+		path string
+		code int
+		want string
+	}{
+		{"not an archive", other, 1, "isn't an archive made with -archive"},
+		{"missing", filepath.Join(dir, "missing.db"), 1, "couldn't open archive"},
+		{"no -db", "", 2, "-db is needed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out, errs bytes.Buffer
+			if code := runArchiveQuery([]string{"-db", test.path}, &out, &errs); code != test.code {
+				t.Errorf("exit code %d, want %d", code, test.code)
+			}
+			if !strings.Contains(errs.String(), test.want) {
+				t.Errorf("error = %q, want it to mention %q", errs.String(), test.want)
+			}
+		})
+	}
+
+	// Neither was changed or created
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !os.IsNotExist(err) {
+		t.Errorf("querying a missing archive created it: %v", err)
+	}
+	db, err = sql.Open("sqlite", other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version, tables int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'quakes'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 || tables != 0 {
+		t.Errorf("query migrated the database: user_version %d, quakes tables %d", version, tables)
+	}
+}
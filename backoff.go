@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors" // Needed to spot rate limiting
+	"time"   // Needed for the delays
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+const (
+	// Longest we'll wait between fetches while they keep failing, unless -refresh is longer
+	MAXBACKOFF = 15 * time.Minute
+
+	// How old the data can get before the footer warns about it, and before it gets urgent
+	STALEWARN  = 5 * time.Minute
+	STALEALERT = 15 * time.Minute
+)
+
+// Works out how long to wait before the next fetch, doubling the wait each time fetches fail
+// in a row so we don't hammer USGS while it's down, eg: 1m, 2m, 4m, 8m, then 15m
+// This should only be used from the update goroutine
+// When the last fetch was rate limited, the server's Retry-After wins if it's longer
+type fetchBackoff struct {
+	refresh    time.Duration // How often to fetch while things are working
+	failures   int           // Fetches that have failed in a row
+	limited    bool          // The last fetch was rate limited
+	retryAfter time.Duration // How long the server asked us to wait, 0 if it didn't say
+}
+
+// Record how a fetch went, anything that got an answer from the server resets the backoff
+func (b *fetchBackoff) record(err error) {
+	var rateLimit *usgs.RateLimitError
+	b.limited = errors.As(err, &rateLimit)
+	b.retryAfter = 0
+	if b.limited {
+		b.retryAfter = rateLimit.RetryAfter
+	}
+
+	if err == nil || err == usgs.ErrNotModified {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+}
+
+// Get how long to wait before the next fetch
+func (b *fetchBackoff) delay() time.Duration {
+	if b.failures == 0 {
+		return b.refresh
+	}
+
+	limit := MAXBACKOFF
+	if b.refresh > limit {
+		limit = b.refresh
+	}
+
+	delay := b.refresh
+	for i := 1; i < b.failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	if b.retryAfter > delay {
+		delay = b.retryAfter
+	}
+
+	return delay
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+func TestBackoffSchedule(t *testing.T) {
+	down := errors.New("connection refused")
+	limited := &usgs.RateLimitError{Status: "429 Too Many Requests", RetryAfter: 20 * time.Minute}
+
+	tests := []struct {
+		name    string
+		refresh time.Duration
+		results []error         // How each fetch went, in order
+		want    []time.Duration // When each fetch after the first happens, from the first
+	}{
+		{
+			name:    "working",
+			refresh: time.Minute,
+			results: []error{nil, nil, usgs.ErrNotModified},
+			want:    []time.Duration{1 * time.Minute, 2 * time.Minute, 3 * time.Minute},
+		},
+		{
+			// 1m, 2m, 4m, 8m, then capped at 15m
+			name:    "outage",
+			refresh: time.Minute,
+			results: []error{down, down, down, down, down, down},
+			want:    []time.Duration{1 * time.Minute, 3 * time.Minute, 7 * time.Minute, 15 * time.Minute, 30 * time.Minute, 45 * time.Minute},
+		},
+		{
+			name:    "recovers",
+			refresh: time.Minute,
+			results: []error{down, down, down, nil, down},
+			want:    []time.Duration{1 * time.Minute, 3 * time.Minute, 7 * time.Minute, 8 * time.Minute, 9 * time.Minute},
+		},
+		{
+			// A refresh longer than the cap is never shortened
+			name:    "slow refresh",
+			refresh: 30 * time.Minute,
+			results: []error{down, down},
+			want:    []time.Duration{30 * time.Minute, 60 * time.Minute},
+		},
+		{
+			name:    "rate limited",
+			refresh: time.Minute,
+			results: []error{limited, down},
+			want:    []time.Duration{20 * time.Minute, 22 * time.Minute},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The clock only moves as far as each wait
+			var clock time.Duration
+			backoff := &fetchBackoff{refresh: test.refresh}
+			var got []time.Duration
+			for _, err := range test.results {
+				backoff.record(err)
+				clock += backoff.delay()
+				got = append(got, clock)
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("fetches at %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("fetches at %v, want %v", got, test.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateFooterDataAge(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		lastChecked time.Time
+		nextUpdate  time.Time
+		want        []string
+		dontWant    []string
+	}{
+		{"no data", time.Time{}, now.Add(time.Minute), []string{"No data yet", "next update in"}, []string{"Data is"}},
+		{"fresh", now.Add(-time.Minute), now.Add(time.Minute), []string{"Data is 1m0s old"}, []string{"[yellow", "[red"}},
+		{"stale", now.Add(-6 * time.Minute), now.Add(4 * time.Minute), []string{"[yellow::b]Data is 6m0s old"}, []string{"[red"}},
+		{"very stale", now.Add(-23 * time.Minute), now.Add(15 * time.Minute), []string{"[red::b]Data is 23m0s old", "next update in 15m0s"}, nil},
+		{"refresh off", now.Add(-time.Minute), time.Time{}, []string{"auto refresh off"}, []string{"next update"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			footer := updateFooter(test.lastChecked, test.lastChecked, test.nextUpdate, 0, 0)
+			for _, want := range test.want {
+				if !strings.Contains(footer, want) {
+					t.Errorf("footer %q doesn't have %q", footer, want)
+				}
+			}
+			for _, dontWant := range test.dontWant {
+				if strings.Contains(footer, dontWant) {
+					t.Errorf("footer %q has %q", footer, dontWant)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRateLimited(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		limited bool
+		want    time.Duration // The wait after two of them in a row
+	}{
+		{"with Retry-After", &usgs.RateLimitError{Status: "429 Too Many Requests", RetryAfter: 10 * time.Minute}, true, 10 * time.Minute},
+		{"without Retry-After", &usgs.RateLimitError{Status: "429 Too Many Requests"}, true, 2 * time.Minute},
+		{"wrapped", fmt.Errorf("fetching all_hour: %w", &usgs.RateLimitError{Status: "503 Service Unavailable", RetryAfter: time.Minute}), true, 2 * time.Minute},
+		{"not rate limited", errors.New("unexpected response: 502 Bad Gateway"), false, 2 * time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backoff := &fetchBackoff{refresh: time.Minute}
+			backoff.record(test.err)
+			backoff.record(test.err)
+			if backoff.limited != test.limited {
+				t.Errorf("limited = %v, want %v", backoff.limited, test.limited)
+			}
+			if got := backoff.delay(); got != test.want {
+				t.Errorf("delay = %v, want %v", got, test.want)
+			}
+
+			// Once a fetch works the server's wait is forgotten
+			backoff.record(nil)
+			if backoff.limited || backoff.delay() != time.Minute {
+				t.Errorf("after a fetch worked: limited = %v, delay = %v, want the refresh", backoff.limited, backoff.delay())
+			}
+		})
+	}
+}
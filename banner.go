@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"  // Needed to format the banner
+	"time" // Needed to expire and rotate the headlines
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/rivo/tview"
+)
+
+// How long a headline stays above the table unless it's dismissed with Esc
+const BANNERDURATION = 10 * time.Minute
+
+// How long each headline is shown before the next one when there's more than one
+const BANNERROTATE = 5 * time.Second
+
+// A big quake's headline, eg: M 7.2 - 104 km SW of Somewhere
+type headline struct {
+	id      string
+	title   string
+	alert   string
+	expires time.Time
+}
+
+// Single line banner above the table with the headlines of big new quakes, taking turns if there's
+// more than one, they're added from the refresh diff and expire on the draw tick
+// Everything but newBanner must be called from the tview event loop
+type banner struct {
+	view      *tview.TextView
+	layout    *tview.Flex // Set once the view is in the layout, so it can be shown and hidden
+	minMag    float64     // 0 turns the banner off
+	headlines []headline
+	current   int             // Index of the headline being shown
+	rotated   time.Time       // When current was last moved on
+	dismissed map[string]bool // By ID, so a dismissed headline doesn't come back on the next refresh
+}
+
+// Create the banner for quakes of minMag or bigger, it's hidden until there's a headline
+func newBanner(minMag float64) *banner {
+	return &banner{
+		view:      tview.NewTextView().SetDynamicColors(true),
+		minMag:    minMag,
+		dismissed: make(map[string]bool),
+	}
+}
+
+// Add headlines for new quakes that are big enough, quakes that already have one or were
+// dismissed are skipped
+func (b *banner) add(quakes []usgs.Feature) {
+	if b == nil || b.minMag <= 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, quake := range quakes {
+		mag, ok := quakeMagnitude(quake)
+		if !ok || mag < b.minMag || b.dismissed[quake.ID] || b.has(quake.ID) {
+			continue
+		}
+
+		title := quake.Properties.Title
+		if title == "" {
+			title = fmt.Sprintf("M %s - %s", formatMagnitude(quake), quakePlace(quake))
+		}
+		b.headlines = append(b.headlines, headline{id: quake.ID, title: title, alert: quake.Properties.Alert, expires: now.Add(BANNERDURATION)})
+	}
+	b.draw()
+}
+
+// Check if a quake already has a headline
+func (b *banner) has(id string) bool {
+	for _, line := range b.headlines {
+		if line.id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Take down the headlines for quakes that are gone, eg: deleted
+func (b *banner) remove(ids []string) {
+	if b == nil || len(b.headlines) == 0 {
+		return
+	}
+
+	gone := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		gone[id] = true
+	}
+	b.keep(func(line headline) bool { return !gone[line.id] })
+}
+
+// Drop expired headlines and move on to the next one if it's time, called every draw tick
+func (b *banner) tick() {
+	if b == nil || len(b.headlines) == 0 {
+		return
+	}
+
+	now := time.Now()
+	if len(b.headlines) > 1 && now.Sub(b.rotated) >= BANNERROTATE {
+		b.current++
+		b.rotated = now
+	}
+	b.keep(func(line headline) bool { return now.Before(line.expires) })
+}
+
+// Dismiss the headline being shown so it doesn't come back
+// Returns false if there wasn't one, so Esc can do what it normally does
+func (b *banner) dismiss() bool {
+	if b == nil || len(b.headlines) == 0 {
+		return false
+	}
+
+	id := b.headlines[b.current%len(b.headlines)].id
+	b.dismissed[id] = true
+	b.keep(func(line headline) bool { return line.id != id })
+
+	return true
+}
+
+// Keep only the headlines that pass, and redraw
+func (b *banner) keep(pass func(headline) bool) {
+	kept := b.headlines[:0]
+	for _, line := range b.headlines {
+		if pass(line) {
+			kept = append(kept, line)
+		}
+	}
+	b.headlines = kept
+	b.draw()
+}
+
+// Show the current headline colored by its alert level, with how many there are if there's more than one
+// The banner takes no room while there's nothing to show
+func (b *banner) draw() {
+	if b.layout == nil {
+		return
+	}
+	if len(b.headlines) == 0 {
+		b.current = 0
+		b.view.Clear()
+		b.layout.ResizeItem(b.view, 0, 0)
+		return
+	}
+
+	b.current %= len(b.headlines)
+	line := b.headlines[b.current]
+	style := "[black:white:b]"
+	if color, ok := alertColors[line.alert]; ok {
+		style = fmt.Sprintf("[black:#%06x:b]", color.Hex())
+	}
+	text := fmt.Sprintf("%s ⚠ %s ", style, tview.Escape(line.title))
+	if len(b.headlines) > 1 {
+		text += fmt.Sprintf("(%d/%d) ", b.current+1, len(b.headlines))
+	}
+	b.view.SetText(text + "[-:-:-] Esc dismisses")
+	b.layout.ResizeItem(b.view, 1, 0)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os/exec" // Needed to launch the browser
+	"runtime" // Needed to pick the launcher for this OS
+)
+
+// Open a URL in the default browser for this OS
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	return cmd.Start()
+}
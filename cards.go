@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"     // Needed to format the cards
+	"strings" // Needed to join the card details
+
+	"github.com/rivo/tview"
+)
+
+// Show the quakes as two line cards instead of table rows, for feeds with only a few quakes
+// that each deserve more room
+// The table is still kept up to date behind the cards and follows their selection, so the
+// detail pane and all the keys work the same way
+func (q *quakeTable) useCards(title string) *tview.List {
+	q.cards = tview.NewList().SetHighlightFullLine(true).SetWrapAround(false)
+	q.cards.SetBorder(true).SetTitle(title)
+	q.cards.SetChangedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		if !q.syncingCards {
+			q.table.Select(index+1, 0)
+		}
+	})
+
+	return q.cards
+}
+
+// Draw a card for each quake shown in the table, keeping the same one selected
+func (q *quakeTable) renderCards() {
+	if q.cards == nil {
+		return
+	}
+
+	// Adding the first card changes the selection, which mustn't move the table's
+	q.syncingCards = true
+	defer func() { q.syncingCards = false }()
+
+	q.cards.Clear()
+	for _, row := range q.shown {
+		top, bottom := q.cardText(row)
+		q.cards.AddItem(top, bottom, 0, nil)
+	}
+	q.selectCard()
+}
+
+// Select the card for the table's selected row
+func (q *quakeTable) selectCard() {
+	if q.cards == nil {
+		return
+	}
+
+	q.syncingCards = true
+	defer func() { q.syncingCards = false }()
+
+	if selected, _ := q.table.GetSelection(); selected > 0 && selected <= q.cards.GetItemCount() {
+		q.cards.SetCurrentItem(selected - 1)
+	}
+}
+
+// Get the two lines of a quake's card: the magnitude, place, alert, and tsunami flag, then
+// the USGS headline, how many people felt it, and when it happened
+func (q *quakeTable) cardText(row quakeRow) (string, string) {
+	p := row.quake.Properties
+
+	color := fmt.Sprintf("#%06x", q.quakeColor(row.quake).Hex())
+
+	top := fmt.Sprintf("%s[%s::b]M%s[-::-]  %s", q.marker(row), color, formatMagnitude(row.quake), tview.Escape(quakePlace(row.quake)))
+	if p.Status == "deleted" {
+		top = "✗ deleted: " + top
+	}
+	if alertColor, ok := alertColors[p.Alert]; ok {
+		top += fmt.Sprintf("  [black:#%06x] %s alert [-:-]", alertColor.Hex(), strings.ToUpper(p.Alert))
+	}
+	if p.Tsunami == 1 {
+		top += "  [white:darkblue] 🌊 tsunami [-:-]"
+	}
+
+	var details []string
+	if p.Title != "" {
+		details = append(details, tview.Escape(p.Title))
+	}
+	if p.Felt > 0 {
+		details = append(details, fmt.Sprintf("felt by %d", p.Felt))
+	}
+	details = append(details, q.timeText(row))
+
+	return top, "   " + strings.Join(details, " · ")
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"strings" // Needed to split the IDs
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// What happened to a quake since the last fetch
+const (
+	changeNew = iota
+	changeUpdate
+	changeRemove
+)
+
+// Names for each kind of change, as they're written in the stream
+var changeActions = []string{
+	changeNew:    "new",
+	changeUpdate: "update",
+	changeRemove: "remove",
+}
+
+// A quake that's new, updated, or gone since the last fetch
+// Removed quakes are the last version we had of them
+type quakeChange struct {
+	action   int
+	quake    usgs.Feature
+	replaces string // The ID we had the quake under before USGS associated it with this one
+}
+
+// Work out what's changed in the feed since the quake list was last updated, and update it to match
+// The changes are oldest first and go to whatever shows them, eg: the table or the stream, and
+// alerts are raised for the new and updated quakes along the way
+// Also returns how many quakes in the feed were outside the area filters
+func diffQuakes(data usgs.Feed, quakeList map[string]usgs.Feature, filter quakeFilter, alerts quakeAlerts) ([]quakeChange, int) {
+	filter.generated = data.Metadata.Generated
+
+	var changes []quakeChange
+	var hidden int
+
+	// The same quake can come from more than one network, once USGS associates them the
+	// preferred one lists the others in its IDs
+	aliases := aliasedIDs(data.Features)
+
+	// Loop over all the quakes in the list and get the data we want from them, from the bottom so the
+	// oldest come first
+	// The feed isn't reversed in place since sources like -from-file hand back the same slice every time
+	for i := len(data.Features) - 1; i >= 0; i-- {
+		y := data.Features[i]
+		// A duplicate that's still in the feed is dropped in favor of its preferred quake
+		if _, ok := aliases[y.ID]; ok {
+			continue
+		}
+
+		// Quakes outside the area never make it into the quake list, so they're counted every time
+		if y.Properties.Status != "deleted" && filter.outsideArea(y) && filter.watch.match(y) == "" {
+			hidden++
+			continue
+		}
+
+		// Skip quakes we've already added that haven't been updated since
+		// Felt reports pour in after big quakes, so a new count is an update even if nothing else changed,
+		// and so is the preferred solution moving to another network since it's someone else's numbers
+		seen, ok := quakeList[y.ID]
+
+		// A quake we had under another ID carries on from it, so it isn't alerted on again
+		replaces := ""
+		if !ok {
+			for _, id := range quakeAliases(y) {
+				if alias, known := quakeList[id]; known {
+					seen, ok, replaces = alias, true, id
+					break
+				}
+			}
+		}
+
+		if ok && replaces == "" && seen.Properties.Updated == y.Properties.Updated && seen.Properties.Felt == y.Properties.Felt && seen.Properties.Net == y.Properties.Net {
+			continue
+		}
+
+		// Deleted quakes are only interesting if they were in the table
+		if y.Properties.Status == "deleted" {
+			if ok && replaces == "" {
+				changes = append(changes, deleteQuake(quakeList, y, filter))
+			}
+			continue
+		}
+
+		// Filtered quakes aren't tracked so they're checked again on the next update,
+		// that way a quake that gets revised above the minimum magnitude shows up then
+		if !filter.shows(y) {
+			// One we were showing has to come out of the table, eg: it was revised below the minimum
+			// magnitude, or reviewed with -status automatic
+			if ok {
+				delete(quakeList, seen.ID)
+				changes = append(changes, quakeChange{action: changeRemove, quake: seen})
+			}
+			continue
+		}
+
+		var previous *usgs.Feature
+		action := changeNew
+		if ok {
+			previous = &seen
+			action = changeUpdate
+		}
+		alerts.check(y, previous)
+
+		if replaces != "" {
+			delete(quakeList, replaces)
+		}
+		quakeList[y.ID] = y
+		changes = append(changes, quakeChange{action: action, quake: y, replaces: replaces})
+	}
+
+	// Any other duplicates we have are removed, the quake they were merged into has them covered
+	for id, quake := range quakeList {
+		if _, ok := aliases[id]; ok {
+			delete(quakeList, id)
+			changes = append(changes, quakeChange{action: changeRemove, quake: quake})
+		}
+	}
+
+	// Forget old quakes so following doesn't use more and more memory
+	for _, quake := range pruneQuakes(quakeList, filter) {
+		changes = append(changes, quakeChange{action: changeRemove, quake: quake})
+	}
+	alerts.flush()
+
+	return changes, hidden
+}
+
+// Get the other IDs a quake is known by, from the Ids property which lists every ID it's had,
+// eg: ",us7000abcd,ci12345,"
+func quakeAliases(quake usgs.Feature) []string {
+	var aliases []string
+	for _, id := range strings.Split(strings.Trim(quake.Properties.Ids, ","), ",") {
+		if id != "" && id != quake.ID {
+			aliases = append(aliases, id)
+		}
+	}
+
+	return aliases
+}
+
+// Check if a quake lists an ID as one of its aliases
+func listsID(quake usgs.Feature, id string) bool {
+	for _, alias := range quakeAliases(quake) {
+		if alias == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get the IDs that are duplicates of another quake in the feed, mapped to the quake that lists them
+// They're either gone from the feed or still there until USGS catches up, two quakes that list
+// each other are left alone since there's no telling which is preferred
+func aliasedIDs(features []usgs.Feature) map[string]string {
+	current := make(map[string]usgs.Feature, len(features))
+	for _, feature := range features {
+		current[feature.ID] = feature
+	}
+
+	aliases := make(map[string]string)
+	for _, feature := range features {
+		for _, id := range quakeAliases(feature) {
+			if other, ok := current[id]; ok && listsID(other, feature.ID) {
+				continue
+			}
+			aliases[id] = feature.ID
+		}
+	}
+
+	return aliases
+}
+
+// Handle a quake USGS has deleted, either keeping it so it can be shown greyed
+// out, which is an update, or removing it from the quake list
+func deleteQuake(quakeList map[string]usgs.Feature, quake usgs.Feature, filter quakeFilter) quakeChange {
+	if filter.showDeleted {
+		quakeList[quake.ID] = quake
+		return quakeChange{action: changeUpdate, quake: quake}
+	}
+
+	delete(quakeList, quake.ID)
+	return quakeChange{action: changeRemove, quake: quake}
+}
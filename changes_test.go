@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/rivo/tview"
+)
+
+// Make a quake for tests, at millis ms since the epoch and last updated then too
+func testQuake(id string, mag float64, millis int64) usgs.Feature {
+	return usgs.Feature{
+		ID: id,
+		Properties: usgs.Properties{
+			Mag:     &mag,
+			Place:   "Test place " + id,
+			Time:    millis,
+			Updated: millis,
+			Status:  "automatic",
+		},
+		Geometry: usgs.Geometry{Coordinates: []float64{-122.8, 38.8, 10}},
+	}
+}
+
+// Get the IDs of the quakes in some changes, in order
+func changedIDs(changes []quakeChange) []string {
+	var ids []string
+	for _, change := range changes {
+		ids = append(ids, change.quake.ID)
+	}
+
+	return ids
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestDiffQuakesOldestFirstWithoutReordering(t *testing.T) {
+	// Feeds are newest first, and -from-file hands back the same slice on every fetch
+	feed := usgs.Feed{Features: []usgs.Feature{
+		testQuake("c", 3, 3000),
+		testQuake("b", 2, 2000),
+		testQuake("a", 1, 1000),
+	}}
+
+	for fetch := 1; fetch <= 3; fetch++ {
+		changes, _ := diffQuakes(feed, make(map[string]usgs.Feature), quakeFilter{}, quakeAlerts{})
+		if got, want := changedIDs(changes), []string{"a", "b", "c"}; !equalStrings(got, want) {
+			t.Errorf("fetch %d: changes = %v, want %v", fetch, got, want)
+		}
+		if got, want := feed.Features[0].ID, "c"; got != want {
+			t.Fatalf("fetch %d: feed reordered, first quake is %q, want %q", fetch, got, want)
+		}
+	}
+}
+
+func TestDiffQuakesActions(t *testing.T) {
+	quakeList := make(map[string]usgs.Feature)
+	feed := usgs.Feed{Features: []usgs.Feature{testQuake("a", 4, 1000)}}
+	changes, _ := diffQuakes(feed, quakeList, quakeFilter{}, quakeAlerts{})
+	if len(changes) != 1 || changes[0].action != changeNew {
+		t.Fatalf("first fetch: changes = %+v, want one new quake", changes)
+	}
+
+	// Nothing's changed, so there's nothing to do
+	changes, _ = diffQuakes(feed, quakeList, quakeFilter{}, quakeAlerts{})
+	if len(changes) != 0 {
+		t.Fatalf("same feed again: changes = %+v, want none", changes)
+	}
+
+	revised := testQuake("a", 4.5, 1000)
+	revised.Properties.Updated = 2000
+	changes, _ = diffQuakes(usgs.Feed{Features: []usgs.Feature{revised}}, quakeList, quakeFilter{}, quakeAlerts{})
+	if len(changes) != 1 || changes[0].action != changeUpdate {
+		t.Fatalf("revised quake: changes = %+v, want one update", changes)
+	}
+	if mag, _ := quakeMagnitude(quakeList["a"]); mag != 4.5 {
+		t.Errorf("quake list has magnitude %v, want 4.5", mag)
+	}
+}
+
+func TestDiffQuakesOnlyChangedQuakes(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*usgs.Feature)
+		want   []int // The actions for the second fetch
+	}{
+		{"unchanged", func(*usgs.Feature) {}, nil},
+		{"updated", func(q *usgs.Feature) { q.Properties.Updated++ }, []int{changeUpdate}},
+		{"more felt reports", func(q *usgs.Feature) { q.Properties.Felt = 12 }, []int{changeUpdate}},
+		{"another network's solution", func(q *usgs.Feature) { q.Properties.Net = "us" }, []int{changeUpdate}},
+		{"place text only", func(q *usgs.Feature) { q.Properties.Place = "Somewhere else" }, nil},
+		{"deleted", func(q *usgs.Feature) { q.Properties.Status = "deleted"; q.Properties.Updated++ }, []int{changeRemove}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quakeList := make(map[string]usgs.Feature)
+			quake := testQuake("a", 3, 1000)
+			diffQuakes(usgs.Feed{Features: []usgs.Feature{quake}}, quakeList, quakeFilter{}, quakeAlerts{})
+
+			test.change(&quake)
+			changes, _ := diffQuakes(usgs.Feed{Features: []usgs.Feature{quake}}, quakeList, quakeFilter{}, quakeAlerts{})
+			var got []int
+			for _, change := range changes {
+				got = append(got, change.action)
+			}
+			if len(got) != len(test.want) || (len(got) > 0 && got[0] != test.want[0]) {
+				t.Errorf("actions = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffQuakesFilteredQuakesArentTracked(t *testing.T) {
+	quakeList := make(map[string]usgs.Feature)
+	filter := quakeFilter{minMagnitude: 4}
+	small := testQuake("a", 3, 1000)
+	diffQuakes(usgs.Feed{Features: []usgs.Feature{small}}, quakeList, filter, quakeAlerts{})
+	if _, ok := quakeList["a"]; ok {
+		t.Fatal("a quake below the minimum magnitude was tracked")
+	}
+
+	// Once it's revised above the minimum it's new
+	revised := testQuake("a", 4.2, 1000)
+	changes, _ := diffQuakes(usgs.Feed{Features: []usgs.Feature{revised}}, quakeList, filter, quakeAlerts{})
+	if len(changes) != 1 || changes[0].action != changeNew {
+		t.Errorf("changes = %+v, want the quake as new", changes)
+	}
+}
+
+func TestDiffQuakesRevisedBelowTheFilter(t *testing.T) {
+	quakeList := make(map[string]usgs.Feature)
+	filter := quakeFilter{minMagnitude: 4}
+	quake := testQuake("a", 4.2, 1000)
+	diffQuakes(usgs.Feed{Features: []usgs.Feature{quake}}, quakeList, filter, quakeAlerts{})
+
+	// Downgraded below the minimum, the row has to go rather than keep showing M4.2
+	downgraded := testQuake("a", 3.8, 1000)
+	downgraded.Properties.Updated++
+	changes, _ := diffQuakes(usgs.Feed{Features: []usgs.Feature{downgraded}}, quakeList, filter, quakeAlerts{})
+	if len(changes) != 1 || changes[0].action != changeRemove || changes[0].quake.ID != "a" {
+		t.Fatalf("changes = %+v, want a removed", changes)
+	}
+	if _, ok := quakeList["a"]; ok {
+		t.Error("a is still tracked after it was downgraded")
+	}
+
+	// It isn't removed again while it stays below
+	downgraded.Properties.Updated++
+	if changes, _ := diffQuakes(usgs.Feed{Features: []usgs.Feature{downgraded}}, quakeList, filter, quakeAlerts{}); len(changes) != 0 {
+		t.Errorf("next fetch: changes = %+v, want none", changes)
+	}
+}
+
+// Make a day's worth of quakes like the all_day feed, about 300 of them newest first
+func allDayFeed() usgs.Feed {
+	const quakes = 300
+	feed := usgs.Feed{Metadata: usgs.Metadata{Generated: 86400000}}
+	for i := 0; i < quakes; i++ {
+		feed.Features = append(feed.Features, testQuake(fmt.Sprintf("ci%08d", i), float64(i%60)/10, int64(quakes-i)*288000))
+	}
+
+	return feed
+}
+
+func BenchmarkDiffQuakesAllDay(b *testing.B) {
+	feed := allDayFeed()
+	for i := 0; i < b.N; i++ {
+		diffQuakes(feed, make(map[string]usgs.Feature), quakeFilter{}, quakeAlerts{})
+	}
+}
+
+func TestAliasedIDs(t *testing.T) {
+	quake := func(id, ids string) usgs.Feature {
+		q := testQuake(id, 3, 1000)
+		q.Properties.Ids = ids
+		return q
+	}
+
+	tests := []struct {
+		name     string
+		features []usgs.Feature
+		want     map[string]string
+	}{
+		{"none", []usgs.Feature{quake("ci1", ",ci1,"), quake("us1", ",us1,")}, map[string]string{}},
+		{"duplicate still in the feed", []usgs.Feature{quake("us1", ",us1,ci1,"), quake("ci1", ",ci1,")}, map[string]string{"ci1": "us1"}},
+		{"duplicate gone", []usgs.Feature{quake("us1", ",ci1,us1,")}, map[string]string{"ci1": "us1"}},
+		{"several networks", []usgs.Feature{quake("us1", ",us1,ci1,nc1,")}, map[string]string{"ci1": "us1", "nc1": "us1"}},
+		{"listing each other", []usgs.Feature{quake("us1", ",us1,ci1,"), quake("ci1", ",ci1,us1,")}, map[string]string{}},
+		{"no Ids", []usgs.Feature{quake("us1", "")}, map[string]string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := aliasedIDs(test.features)
+			if len(got) != len(test.want) {
+				t.Fatalf("aliases = %v, want %v", got, test.want)
+			}
+			for id, preferred := range test.want {
+				if got[id] != preferred {
+					t.Errorf("aliases = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// Replay the feeds from before, during, and after USGS associated a ci quake with a us one
+// ci40000001 is alone at first, then us7000abcd becomes preferred and lists it while it's still in the
+// feed, and then it's gone
+func TestDiffQuakesAssociation(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		actions  []int
+		ids      []string // Of the changes
+		replaces string   // For the update
+		tracked  []string
+	}{
+		{"association_1.geojson", []int{changeNew, changeNew}, []string{"nc73524811", "ci40000001"}, "", []string{"ci40000001", "nc73524811"}},
+		{"association_2.geojson", []int{changeUpdate}, []string{"us7000abcd"}, "ci40000001", []string{"nc73524811", "us7000abcd"}},
+		{"association_3.geojson", []int{changeUpdate}, []string{"us7000abcd"}, "", []string{"nc73524811", "us7000abcd"}},
+	}
+
+	quakeList := make(map[string]usgs.Feature)
+	before := make(map[string]usgs.Feature)
+	for _, test := range tests {
+		feed, err := readFeedFile("testdata/" + test.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, quake := range quakeList {
+			before[id] = quake
+		}
+
+		changes, _ := diffQuakes(feed, quakeList, quakeFilter{}, quakeAlerts{})
+		var actions []int
+		replaces := ""
+		for _, change := range changes {
+			actions = append(actions, change.action)
+			if change.replaces != "" {
+				replaces = change.replaces
+			}
+		}
+		if len(actions) != len(test.actions) || !equalStrings(changedIDs(changes), test.ids) {
+			t.Errorf("%s: changes = %+v, want %v for %v", test.fixture, changes, test.actions, test.ids)
+		}
+		for i := range actions {
+			if i < len(test.actions) && actions[i] != test.actions[i] {
+				t.Errorf("%s: actions = %v, want %v", test.fixture, actions, test.actions)
+				break
+			}
+		}
+		if replaces != test.replaces {
+			t.Errorf("%s: replaces %q, want %q", test.fixture, replaces, test.replaces)
+		}
+
+		var tracked []string
+		for id := range quakeList {
+			tracked = append(tracked, id)
+		}
+		sort.Strings(tracked)
+		if !equalStrings(tracked, test.tracked) {
+			t.Errorf("%s: tracking %v, want %v", test.fixture, tracked, test.tracked)
+		}
+
+		// The association is one quake changing its magnitude, not a new quake and a removed one
+		if test.replaces != "" {
+			lines := diffLines(changes, before, quakeList, quakeFilter{})
+			if len(lines) != 1 || !strings.HasPrefix(lines[0], "~ us7000abcd") || !strings.Contains(lines[0], "M 3.62 → 3.70") {
+				t.Errorf("%s: diff = %q, want the magnitude change", test.fixture, lines)
+			}
+		}
+	}
+}
+
+func TestApplyAssociationKeepsTheRow(t *testing.T) {
+	q := newQuakeTable(tview.NewTable(), []int{columnMagnitude, columnLocation}, colorScale{}, nil, false)
+	ci := testQuake("ci40000001", 3.6, 2000)
+	other := testQuake("nc73524811", 1.3, 1000)
+	q.apply([]quakeRow{{quake: ci, cells: formatRow(ci)}, {quake: other, cells: formatRow(other)}}, nil)
+	q.selectID("ci40000001")
+	q.dismissed = map[string]bool{"ci40000001": true}
+	q.revealDismissed = true
+
+	us := testQuake("us7000abcd", 3.7, 2000)
+	us.Properties.Ids = ",us7000abcd,ci40000001,"
+	q.apply([]quakeRow{{quake: us, cells: formatRow(us), replaces: "ci40000001"}}, nil)
+
+	if got, want := rowIDs(q.shown), []string{"us7000abcd", "nc73524811"}; !equalStrings(got, want) {
+		t.Errorf("shown = %v, want %v", got, want)
+	}
+	if got := q.selectedID(); got != "us7000abcd" {
+		t.Errorf("selected %q, want the preferred quake", got)
+	}
+	if !q.dismissed["us7000abcd"] {
+		t.Error("the preferred quake isn't dismissed like the one it replaced")
+	}
+	row, _ := q.events.get("us7000abcd")
+	if len(row.revisions) != 1 || row.revisions[0].from != 3.6 || row.revisions[0].to != 3.7 {
+		t.Errorf("revisions = %+v, want 3.6 to 3.7", row.revisions)
+	}
+	if details := formatDetails(us, time.UTC); !strings.Contains(details, "ci40000001, from other networks") {
+		t.Errorf("details don't mention the other ID:\n%s", details)
+	}
+}
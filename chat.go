@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"      // Needed to format the messages
+	"net/http" // Needed to POST to the chat
+	"sort"     // Needed to list the biggest quakes first
+	"strings"  // Needed to build the message text
+	"sync"     // Needed to wait for messages that are still being posted
+	"time"     // Needed for the message times
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// More quakes than this in one refresh are summed up in one message instead of one each, so a
+// swarm doesn't flood the channel
+const CHATBATCH = 5
+
+// Most quakes listed in a summed up message
+const CHATLISTED = 10
+
+// Chat services we can format messages for
+const (
+	chatSlack = iota
+	chatDiscord
+)
+
+// Emoji for the PAGER alert levels, since chat messages can't be colored by alert and magnitude both
+var alertEmoji = map[string]string{
+	"green":  "🟢",
+	"yellow": "🟡",
+	"orange": "🟠",
+	"red":    "🔴",
+}
+
+// Posts big quakes to a Slack or Discord channel as formatted messages
+// The quakes from each refresh are queued and sent together once it's done
+type chatWebhook struct {
+	url      string
+	provider int // One of the chat constants
+	minMag   float64
+	colors   colorScale
+	location *time.Location
+	client   *http.Client
+	pending  []usgs.Feature // Only used from the update goroutine
+	failed   func(error)    // Called when a message can't be posted
+	posting  sync.WaitGroup // Messages still being posted, so they aren't cut off when we exit
+}
+
+// Create a chat webhook for quakes at or above minMag, provider is one of the chat constants
+func newChatWebhook(url string, provider int, minMag float64, colors colorScale, location *time.Location, failed func(error)) *chatWebhook {
+	return &chatWebhook{
+		url:      url,
+		provider: provider,
+		minMag:   minMag,
+		colors:   colors,
+		location: location,
+		client:   &http.Client{Timeout: WEBHOOKTIMEOUT},
+		failed:   failed,
+	}
+}
+
+// Check if a quake should be posted, without a minimum magnitude every new quake is
+func (c *chatWebhook) wants(quake usgs.Feature, previous *usgs.Feature) bool {
+	if c.minMag <= 0 {
+		return previous == nil
+	}
+
+	return crossedThreshold(c.minMag, quake, previous)
+}
+
+// Queue a quake to be posted when the refresh is done
+func (c *chatWebhook) send(quake usgs.Feature) {
+	c.pending = append(c.pending, quake)
+}
+
+// Post the quakes queued during a refresh in the background, one message each, or one for all
+// of them if there are lots
+func (c *chatWebhook) flush() {
+	if c == nil || len(c.pending) == 0 {
+		return
+	}
+
+	quakes := c.pending
+	c.pending = nil
+
+	var payloads []interface{}
+	if len(quakes) > CHATBATCH {
+		payloads = append(payloads, c.summaryPayload(quakes))
+	} else {
+		for _, quake := range quakes {
+			payloads = append(payloads, c.quakePayload(quake))
+		}
+	}
+
+	c.posting.Add(1)
+	go func() {
+		defer c.posting.Done()
+		for _, payload := range payloads {
+			if err := postJSON(c.client, c.url, payload); err != nil && c.failed != nil {
+				c.failed(fmt.Errorf("chat message failed: %v", err))
+			}
+		}
+	}()
+}
+
+// Wait for messages that are still being posted, eg: before a one-shot run exits
+func (c *chatWebhook) Close() {
+	if c == nil {
+		return
+	}
+
+	waitPosts(&c.posting)
+}
+
+// Build the message for one quake
+func (c *chatWebhook) quakePayload(quake usgs.Feature) interface{} {
+	if c.provider == chatDiscord {
+		return discordQuakePayload(quake, c.colors, c.location)
+	}
+
+	return slackQuakePayload(quake, c.colors, c.location)
+}
+
+// Build one message for lots of quakes
+func (c *chatWebhook) summaryPayload(quakes []usgs.Feature) interface{} {
+	if c.provider == chatDiscord {
+		return discordSummaryPayload(quakes, c.minMag, c.colors, c.location)
+	}
+
+	return slackSummaryPayload(quakes, c.minMag, c.colors, c.location)
+}
+
+// A field shown in a chat message
+type chatField struct {
+	name  string
+	value string
+}
+
+// Get the title for a quake's message, eg: M 6.1 - 10 km S of Town
+func chatTitle(quake usgs.Feature) string {
+	if quake.Properties.Title != "" {
+		return quake.Properties.Title
+	}
+
+	return "M " + strings.TrimSpace(formatMagnitude(quake)) + " - " + quakePlace(quake)
+}
+
+// Get the fields for a quake's message
+func chatFields(quake usgs.Feature, location *time.Location) []chatField {
+	alert := "none"
+	if quake.Properties.Alert != "" {
+		alert = strings.TrimSpace(alertEmoji[quake.Properties.Alert] + " " + quake.Properties.Alert)
+	}
+	tsunami := "No"
+	if quake.Properties.Tsunami == 1 {
+		tsunami = "🌊 Yes, check tsunami.gov"
+	}
+	depth := formatDepth(quake.Geometry)
+	if _, ok := quakeDepth(quake); ok {
+		depth += " km"
+	}
+	local := formatEpicenterTime(quake, "Jan 2 15:04 MST")
+	if _, ok := epicenterTime(quake); !ok {
+		local = formatTime(quake.Properties.Time, location)
+	}
+
+	return []chatField{
+		{"Magnitude", strings.TrimSpace(formatMagnitude(quake) + " " + formatMagType(quake.Properties.MagType))},
+		{"Depth", depth},
+		{"Local time", local},
+		{"Alert", alert},
+		{"Tsunami", tsunami},
+	}
+}
+
+// Get the heading for a summed up message, eg: 12 quakes at or above M4.5
+func chatSummaryTitle(count int, minMag float64) string {
+	if minMag > 0 {
+		return fmt.Sprintf("%d quakes at or above M%.1f", count, minMag)
+	}
+
+	return fmt.Sprintf("%d new quakes", count)
+}
+
+// Sort quakes biggest first for a summed up message, quakes without a magnitude go last
+func biggestFirst(quakes []usgs.Feature) []usgs.Feature {
+	sorted := append([]usgs.Feature{}, quakes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aOK := quakeMagnitude(sorted[i])
+		b, bOK := quakeMagnitude(sorted[j])
+		return aOK && (!bOK || a > b)
+	})
+
+	return sorted
+}
+
+// A Slack message with the quake in a colored attachment, see:
+// https://api.slack.com/reference/messaging/attachments
+type slackPayload struct {
+	Text        string            `json:"text"` // Shown in notifications
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Slack wants these escaped in message text
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Build the Slack message for one quake
+func slackQuakePayload(quake usgs.Feature, colors colorScale, location *time.Location) slackPayload {
+	title := slackEscaper.Replace(chatTitle(quake))
+
+	var fields []slackText
+	for _, field := range chatFields(quake, location) {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*" + field.name + "*\n" + slackEscaper.Replace(field.value)})
+	}
+
+	return slackPayload{
+		Text: title,
+		Attachments: []slackAttachment{{
+			Color: fmt.Sprintf("#%06x", magnitudeColor(colors, quake).Hex()),
+			Blocks: []slackBlock{
+				{Type: "section", Text: &slackText{Type: "mrkdwn", Text: fmt.Sprintf("*<%s|%s>*", quake.Properties.URL, title)}},
+				{Type: "section", Fields: fields},
+			},
+		}},
+	}
+}
+
+// Build the Slack message for lots of quakes, listing the biggest
+func slackSummaryPayload(quakes []usgs.Feature, minMag float64, colors colorScale, location *time.Location) slackPayload {
+	title := chatSummaryTitle(len(quakes), minMag)
+
+	quakes = biggestFirst(quakes)
+	var lines []string
+	for i, quake := range quakes {
+		if i == CHATLISTED {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(quakes)-CHATLISTED))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• <%s|%s> · %s", quake.Properties.URL, slackEscaper.Replace(chatTitle(quake)), formatTime(quake.Properties.Time, location)))
+	}
+
+	return slackPayload{
+		Text: title,
+		Attachments: []slackAttachment{{
+			Color: fmt.Sprintf("#%06x", magnitudeColor(colors, quakes[0]).Hex()),
+			Blocks: []slackBlock{
+				{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + title + "*\n" + strings.Join(lines, "\n")}},
+			},
+		}},
+	}
+}
+
+// A Discord message with the quake in an embed, see:
+// https://discord.com/developers/docs/resources/message#embed-object
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Color       int32          `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Build the Discord message for one quake
+func discordQuakePayload(quake usgs.Feature, colors colorScale, location *time.Location) discordPayload {
+	var fields []discordField
+	for _, field := range chatFields(quake, location) {
+		fields = append(fields, discordField{Name: field.name, Value: field.value, Inline: true})
+	}
+
+	return discordPayload{Embeds: []discordEmbed{{
+		Title:     chatTitle(quake),
+		URL:       quake.Properties.URL,
+		Color:     magnitudeColor(colors, quake).Hex(),
+		Fields:    fields,
+		Timestamp: fromMillis(quake.Properties.Time).UTC().Format(time.RFC3339),
+	}}}
+}
+
+// Build the Discord message for lots of quakes, listing the biggest
+func discordSummaryPayload(quakes []usgs.Feature, minMag float64, colors colorScale, location *time.Location) discordPayload {
+	quakes = biggestFirst(quakes)
+	var lines []string
+	for i, quake := range quakes {
+		if i == CHATLISTED {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(quakes)-CHATLISTED))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• [%s](%s) · %s", chatTitle(quake), quake.Properties.URL, formatTime(quake.Properties.Time, location)))
+	}
+
+	return discordPayload{Embeds: []discordEmbed{{
+		Title:       chatSummaryTitle(len(quakes), minMag),
+		Description: strings.Join(lines, "\n"),
+		Color:       magnitudeColor(colors, quakes[0]).Hex(),
+	}}}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/gdamore/tcell"
+)
+
+// Orange from M5 and red from M6, so messages can be checked for the right color
+var chatColors = colorScale{{5, tcell.NewHexColor(0xff8800)}, {6, tcell.NewHexColor(0xff0000)}}
+
+// Make a big quake with everything a chat message shows
+func chatQuake() usgs.Feature {
+	quake := testQuake("us7000abcd", 6.1, millis(time.Date(2021, 3, 1, 18, 30, 0, 0, time.UTC)))
+	quake.Properties.Title = "M 6.1 - 10 km S of Town <Coast> & Sea"
+	quake.Properties.URL = "https://earthquake.usgs.gov/earthquakes/eventpage/us7000abcd"
+	quake.Properties.MagType = "Mww"
+	quake.Properties.Alert = "orange"
+	quake.Properties.Tsunami = 1
+	tz := int64(540)
+	quake.Properties.Tz = &tz
+
+	return quake
+}
+
+// Make n quakes from M5.0 up, in the order they'd come in
+func chatSwarm(n int) []usgs.Feature {
+	var quakes []usgs.Feature
+	for i := 0; i < n; i++ {
+		quake := testQuake(fmt.Sprintf("ci%d", i), 5+float64(i)/10, int64(i)*1000)
+		quake.Properties.URL = "https://example.com/" + quake.ID
+		quakes = append(quakes, quake)
+	}
+
+	return quakes
+}
+
+func TestChatFields(t *testing.T) {
+	quiet := testQuake("ci1", 4.2, millis(time.Date(2021, 3, 1, 18, 30, 0, 0, time.UTC)))
+	quiet.Geometry.Coordinates = quiet.Geometry.Coordinates[:2]
+
+	tests := []struct {
+		name  string
+		quake usgs.Feature
+		want  []chatField
+	}{
+		{"everything", chatQuake(), []chatField{
+			{"Magnitude", "6.10 mww"},
+			{"Depth", "10.0 km"},
+			{"Local time", "Mar 2 03:30 UTC+09:00"},
+			{"Alert", "🟠 orange"},
+			{"Tsunami", "🌊 Yes, check tsunami.gov"},
+		}},
+		// Without Tz the time is shown in -timezone instead
+		{"nothing extra", quiet, []chatField{
+			{"Magnitude", "4.20"},
+			{"Depth", "-"},
+			{"Local time", formatTime(quiet.Properties.Time, time.UTC)},
+			{"Alert", "none"},
+			{"Tsunami", "No"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := chatFields(test.quake, time.UTC)
+			if len(got) != len(test.want) {
+				t.Fatalf("fields = %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("field %d = %+v, want %+v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSlackQuakePayload(t *testing.T) {
+	payload := slackQuakePayload(chatQuake(), chatColors, time.UTC)
+
+	// Slack's own markup is escaped so the title can't turn into a link
+	title := "M 6.1 - 10 km S of Town &lt;Coast&gt; &amp; Sea"
+	if payload.Text != title {
+		t.Errorf("text = %q, want %q", payload.Text, title)
+	}
+	if len(payload.Attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(payload.Attachments))
+	}
+	attachment := payload.Attachments[0]
+	if attachment.Color != "#ff0000" {
+		t.Errorf("color = %q, want the M6 color #ff0000", attachment.Color)
+	}
+	if len(attachment.Blocks) != 2 || attachment.Blocks[0].Text == nil {
+		t.Fatalf("blocks = %+v, want a heading and the fields", attachment.Blocks)
+	}
+	if got, want := attachment.Blocks[0].Text.Text, "*<"+chatQuake().Properties.URL+"|"+title+">*"; got != want {
+		t.Errorf("heading = %q, want %q", got, want)
+	}
+	if got := attachment.Blocks[1].Fields; len(got) != 5 || got[0].Text != "*Magnitude*\n6.10 mww" || got[0].Type != "mrkdwn" {
+		t.Errorf("fields = %+v, want the magnitude first", got)
+	}
+
+	// Slack only takes the field names it knows
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), `"fields":null`) || strings.Contains(string(encoded), `"text":null`) {
+		t.Errorf("payload has empty fields: %s", encoded)
+	}
+}
+
+func TestDiscordQuakePayload(t *testing.T) {
+	payload := discordQuakePayload(chatQuake(), chatColors, time.UTC)
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("got %d embeds, want 1", len(payload.Embeds))
+	}
+
+	embed := payload.Embeds[0]
+	if embed.Title != chatQuake().Properties.Title || embed.URL != chatQuake().Properties.URL {
+		t.Errorf("embed is %q linking to %q, want the quake's title and page", embed.Title, embed.URL)
+	}
+	if embed.Color != 0xff0000 {
+		t.Errorf("color = %#x, want the M6 color 0xff0000", embed.Color)
+	}
+	if embed.Timestamp != "2021-03-01T18:30:00Z" {
+		t.Errorf("timestamp = %q, want the quake's time in UTC", embed.Timestamp)
+	}
+	if len(embed.Fields) != 5 || embed.Fields[4] != (discordField{Name: "Tsunami", Value: "🌊 Yes, check tsunami.gov", Inline: true}) {
+		t.Errorf("fields = %+v, want the tsunami flag last", embed.Fields)
+	}
+}
+
+func TestChatSummaryPayloads(t *testing.T) {
+	tests := []struct {
+		name   string
+		quakes int
+		minMag float64
+		title  string
+		lines  int
+	}{
+		{"a few", 6, 5, "6 quakes at or above M5.0", 6},
+		{"too many to list", 14, 0, "14 new quakes", CHATLISTED + 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quakes := chatSwarm(test.quakes)
+			biggest := quakes[len(quakes)-1]
+
+			slack := slackSummaryPayload(quakes, test.minMag, chatColors, time.UTC)
+			discord := discordSummaryPayload(quakes, test.minMag, chatColors, time.UTC)
+			slackLines := strings.Split(slack.Attachments[0].Blocks[0].Text.Text, "\n")[1:]
+			discordLines := strings.Split(discord.Embeds[0].Description, "\n")
+
+			if slack.Text != test.title || discord.Embeds[0].Title != test.title {
+				t.Errorf("titles = %q and %q, want %q", slack.Text, discord.Embeds[0].Title, test.title)
+			}
+			if len(slackLines) != test.lines || len(discordLines) != test.lines {
+				t.Fatalf("listed %d and %d lines, want %d", len(slackLines), len(discordLines), test.lines)
+			}
+
+			// The biggest is listed first and sets the color
+			if !strings.Contains(slackLines[0], biggest.Properties.URL) || !strings.Contains(discordLines[0], biggest.Properties.URL) {
+				t.Errorf("first lines are %q and %q, want %s", slackLines[0], discordLines[0], biggest.ID)
+			}
+			wantColor := magnitudeColor(chatColors, biggest).Hex()
+			if slack.Attachments[0].Color != fmt.Sprintf("#%06x", wantColor) || discord.Embeds[0].Color != wantColor {
+				t.Errorf("colors = %s and %#x, want the biggest quake's %#x", slack.Attachments[0].Color, discord.Embeds[0].Color, wantColor)
+			}
+			if test.quakes > CHATLISTED {
+				more := fmt.Sprintf("…and %d more", test.quakes-CHATLISTED)
+				if slackLines[CHATLISTED] != more || discordLines[CHATLISTED] != more {
+					t.Errorf("last lines are %q and %q, want %q", slackLines[CHATLISTED], discordLines[CHATLISTED], more)
+				}
+			}
+		})
+	}
+}
+
+func TestChatWebhookBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider int
+		quakes   int
+		posts    int
+	}{
+		{"one each on slack", chatSlack, CHATBATCH, CHATBATCH},
+		{"summed up on slack", chatSlack, CHATBATCH + 1, 1},
+		{"one each on discord", chatDiscord, 2, 2},
+		{"summed up on discord", chatDiscord, 40, 1},
+		{"nothing", chatDiscord, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var bodies []map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				bodies = append(bodies, body)
+				mu.Unlock()
+			}))
+			defer server.Close()
+
+			c := newChatWebhook(server.URL, test.provider, 5, chatColors, time.UTC, func(err error) { t.Error(err) })
+			for _, quake := range chatSwarm(test.quakes) {
+				c.send(quake)
+			}
+			c.flush()
+			c.Close()
+
+			if len(bodies) != test.posts {
+				t.Fatalf("got %d posts, want %d", len(bodies), test.posts)
+			}
+			want := "attachments"
+			if test.provider == chatDiscord {
+				want = "embeds"
+			}
+			for _, body := range bodies {
+				if _, ok := body[want]; !ok {
+					t.Errorf("post = %v, want %s in it", body, want)
+				}
+			}
+
+			// Everything was sent, so the next refresh starts again
+			if len(c.pending) != 0 {
+				t.Errorf("%d quakes still pending", len(c.pending))
+			}
+		})
+	}
+}
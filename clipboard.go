@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/base64" // Needed to encode the OSC 52 sequence
+	"fmt"             // Needed to write the OSC 52 sequence
+	"io"              // Needed to write to the terminal
+	"os"              // Needed to check for Wayland
+	"os/exec"         // Needed to run the clipboard tools
+	"runtime"         // Needed to pick the clipboard tool for this OS
+	"strings"         // Needed to hand the text to the clipboard tool
+)
+
+// Copy text to the clipboard
+// The terminal is asked to do it with an OSC 52 escape sequence, which works over SSH in most
+// terminals, and the text is also handed to this OS's clipboard tool if there is one since not
+// every terminal supports OSC 52
+// This writes to the terminal, so it must be called from the tview event loop so it can't land
+// in the middle of a redraw
+func copyToClipboard(terminal io.Writer, text string) error {
+	_, err := fmt.Fprintf(terminal, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+
+	cmd := clipboardCommand()
+	if cmd == nil {
+		return err
+	}
+
+	// Some clipboard tools hang around to serve the clipboard, so we don't wait for them
+	cmd.Stdin = strings.NewReader(text)
+	go cmd.Run()
+
+	return nil
+}
+
+// Get the command that copies its input to the clipboard on this OS, nil if there isn't one
+func clipboardCommand() *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("pbcopy")
+	case "windows":
+		return exec.Command("clip")
+	}
+
+	candidates := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][]string{{"wl-copy"}}, candidates...)
+	}
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err == nil {
+			return exec.Command(candidate[0], candidate[1:]...)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"math"    // Needed to round the legend ranges
+	"sort"    // Needed to order the thresholds
+	"strconv" // Needed to parse the thresholds
+	"strings" // Needed to split the scale
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/gdamore/tcell"
+)
+
+const (
+	// The magnitude colors we use unless told otherwise, anything below the first is green
+	DEFAULTCOLORSCALE = "4:yellow,6:orange,7:red"
+
+	// Depths in km where quakes stop being shallow and start being deep, when coloring by depth
+	INTERMEDIATEDEPTH = 70
+	DEEPDEPTH         = 300
+
+	// Terminals shorter than this don't get the legend, the table needs the room more
+	LEGENDMINHEIGHT = 20
+)
+
+// Ways of coloring the quakes, in the same order as colorModes
+const (
+	colorByMagnitude = iota
+	colorByDepth
+	colorByAlert
+)
+
+// A way of coloring the quakes, with the name used to pick it, the color for each quake, and
+// a legend explaining the colors
+type colorMode struct {
+	name   string
+	color  func(colorScale, usgs.Feature) tcell.Color
+	legend func(colorScale) string
+}
+
+// Every way the quakes can be colored, 'c' cycles through them in this order
+var colorModes = []colorMode{
+	colorByMagnitude: {"mag", magnitudeColor, magnitudeLegend},
+	colorByDepth:     {"depth", depthColor, depthLegend},
+	colorByAlert:     {"alert", alertColor, alertLegend},
+}
+
+// Get the color mode with the given name
+func parseColorMode(name string) (int, error) {
+	var names []string
+	for mode, colorMode := range colorModes {
+		if colorMode.name == name {
+			return mode, nil
+		}
+		names = append(names, colorMode.name)
+	}
+
+	return 0, fmt.Errorf("invalid color mode %q: must be one of %s", name, strings.Join(names, ", "))
+}
+
+// Color quakes by magnitude with the color scale, quakes without a magnitude are white
+func magnitudeColor(scale colorScale, quake usgs.Feature) tcell.Color {
+	mag, ok := quakeMagnitude(quake)
+	if !ok {
+		return tcell.ColorWhite
+	}
+
+	return scale.color(mag)
+}
+
+// Color quakes by depth, shallow quakes do the most damage so they're red
+func depthColor(scale colorScale, quake usgs.Feature) tcell.Color {
+	depth, ok := quakeDepth(quake)
+	switch {
+	case !ok:
+		return tcell.ColorWhite
+	case depth < INTERMEDIATEDEPTH:
+		return tcell.ColorRed
+	case depth < DEEPDEPTH:
+		return tcell.ColorYellow
+	}
+
+	return tcell.ColorBlue
+}
+
+// Color quakes by their PAGER alert level, quakes without one are white
+func alertColor(scale colorScale, quake usgs.Feature) tcell.Color {
+	if color, ok := alertColors[quake.Properties.Alert]; ok {
+		return color
+	}
+
+	return tcell.ColorWhite
+}
+
+// Format one entry of a legend, a dot in the color and what it means, eg: ● 4–5.9
+func legendEntry(color tcell.Color, meaning string) string {
+	return fmt.Sprintf("  [#%06x]●[-] %s", color.Hex(), meaning)
+}
+
+// Build the legend for the magnitude colors from the scale, eg: Mag: ● <4  ● 4–5.9  ● 6–6.9  ● 7+
+func magnitudeLegend(scale colorScale) string {
+	if len(scale) == 0 {
+		return "Mag:" + legendEntry(tcell.ColorGreen, "all")
+	}
+
+	legend := "Mag:" + legendEntry(tcell.ColorGreen, fmt.Sprintf("<%g", scale[0].magnitude))
+	for i, threshold := range scale {
+		if i == len(scale)-1 {
+			legend += legendEntry(threshold.color, fmt.Sprintf("%g+", threshold.magnitude))
+			continue
+		}
+
+		// Magnitudes are shown to a tenth, so each range ends a tenth below the next one
+		upper := math.Round(scale[i+1].magnitude*10-1) / 10
+		legend += legendEntry(threshold.color, fmt.Sprintf("%g–%g", threshold.magnitude, upper))
+	}
+
+	return legend
+}
+
+// Build the legend for the depth colors
+func depthLegend(scale colorScale) string {
+	return "Depth:" +
+		legendEntry(tcell.ColorRed, fmt.Sprintf("<%d km", INTERMEDIATEDEPTH)) +
+		legendEntry(tcell.ColorYellow, fmt.Sprintf("%d–%d km", INTERMEDIATEDEPTH, DEEPDEPTH)) +
+		legendEntry(tcell.ColorBlue, fmt.Sprintf("%d+ km", DEEPDEPTH))
+}
+
+// Build the legend for the alert colors
+func alertLegend(scale colorScale) string {
+	legend := "Alert:"
+	for _, level := range []string{"green", "yellow", "orange", "red"} {
+		legend += legendEntry(alertColors[level], level)
+	}
+
+	return legend + legendEntry(tcell.ColorWhite, "none")
+}
+
+// A magnitude and the color to use for quakes at or above it
+type colorThreshold struct {
+	magnitude float64
+	color     tcell.Color
+}
+
+// Magnitude thresholds ordered from smallest to largest
+type colorScale []colorThreshold
+
+// Parse a color scale like "3:yellow,5:orange,6.5:red"
+// The thresholds can be given in any order but each magnitude can only be used once
+func parseColorScale(value string) (colorScale, error) {
+	var scale colorScale
+	for _, part := range strings.Split(value, ",") {
+		pieces := strings.Split(strings.TrimSpace(part), ":")
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("invalid color scale %q: expected magnitude:color pairs like 4:yellow", value)
+		}
+
+		magnitude, err := strconv.ParseFloat(pieces[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color scale %q: %q is not a magnitude", value, pieces[0])
+		}
+
+		color, ok := tcell.ColorNames[strings.ToLower(pieces[1])]
+		if !ok {
+			return nil, fmt.Errorf("invalid color scale %q: unknown color %q", value, pieces[1])
+		}
+
+		scale = append(scale, colorThreshold{magnitude: magnitude, color: color})
+	}
+
+	sort.Slice(scale, func(i, j int) bool {
+		return scale[i].magnitude < scale[j].magnitude
+	})
+	for i := 1; i < len(scale); i++ {
+		if scale[i].magnitude == scale[i-1].magnitude {
+			return nil, fmt.Errorf("invalid color scale %q: magnitude %g is used more than once", value, scale[i].magnitude)
+		}
+	}
+
+	return scale, nil
+}
+
+// Get the color for a magnitude, green if it's below every threshold
+func (c colorScale) color(magnitude float64) tcell.Color {
+	color := tcell.ColorGreen
+	for _, threshold := range c {
+		if magnitude < threshold.magnitude {
+			break
+		}
+		color = threshold.color
+	}
+
+	return color
+}
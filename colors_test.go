@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+func TestParseColorScale(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    colorScale
+		wantErr string
+	}{
+		{value: "3:yellow,5:orange,6.5:red", want: colorScale{{3, tcell.ColorYellow}, {5, tcell.ColorOrange}, {6.5, tcell.ColorRed}}},
+		{value: "6.5:Red, 3:yellow", want: colorScale{{3, tcell.ColorYellow}, {6.5, tcell.ColorRed}}},
+		{value: "3:yellow,3:red", wantErr: "used more than once"},
+		{value: "3-yellow", wantErr: "expected magnitude:color pairs"},
+		{value: "big:red", wantErr: "is not a magnitude"},
+		{value: "3:mauvish", wantErr: "unknown color"},
+	}
+
+	for _, test := range tests {
+		scale, err := parseColorScale(test.value)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("parseColorScale(%q) err = %v, want one mentioning %q", test.value, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseColorScale(%q): %v", test.value, err)
+			continue
+		}
+		if len(scale) != len(test.want) {
+			t.Errorf("parseColorScale(%q) = %v, want %v", test.value, scale, test.want)
+			continue
+		}
+		for i := range scale {
+			if scale[i] != test.want[i] {
+				t.Errorf("parseColorScale(%q) = %v, want %v", test.value, scale, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestColorScaleColor(t *testing.T) {
+	scale := colorScale{{3, tcell.ColorYellow}, {5, tcell.ColorOrange}, {6.5, tcell.ColorRed}}
+	tests := []struct {
+		magnitude float64
+		want      tcell.Color
+	}{
+		{-0.5, tcell.ColorGreen},
+		{2.99, tcell.ColorGreen},
+		{3, tcell.ColorYellow},
+		{4.9, tcell.ColorYellow},
+		{5, tcell.ColorOrange},
+		{6.5, tcell.ColorRed},
+		{9.1, tcell.ColorRed},
+	}
+
+	for _, test := range tests {
+		if got := scale.color(test.magnitude); got != test.want {
+			t.Errorf("color(%v) = %v, want %v", test.magnitude, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"     // Needed to format the column values
+	"strings" // Needed to split the column list
+	"time"    // Needed for the epicenter time
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Table columns, in the same order as tableColumns and the cells from formatRow
+const (
+	columnID = iota
+	columnTime
+	columnMagnitude
+	columnDepth
+	columnDistance
+	columnLocation
+	columnTsunami
+	columnAlert
+	columnIDs
+	columnFelt
+	columnSig
+	columnMagType
+	columnStatus
+	columnNetwork
+	columnNst
+	columnGap
+	columnRms
+	columnDmin
+	columnSource
+	columnReviewed
+	columnLocalTime
+	columnUpdated
+	columnCoordinates
+)
+
+// A column the table can show, with the name used to pick it and how to get its text
+// Columns with no text func depend on display settings so the table fills those in
+type tableColumn struct {
+	name   string
+	header string
+	text   func(usgs.Feature) string
+}
+
+// Every column the table can show
+var tableColumns = []tableColumn{
+	columnID:          {"id", "ID", func(y usgs.Feature) string { return y.ID }},
+	columnTime:        {"time", "Time", nil},
+	columnMagnitude:   {"mag", "Magnitude", formatMagnitude},
+	columnDepth:       {"depth", "Depth (km)", func(y usgs.Feature) string { return formatDepth(y.Geometry) }},
+	columnDistance:    {"distance", "Distance", nil},
+	columnLocation:    {"place", "Location", quakePlace},
+	columnTsunami:     {"tsunami", "T", func(y usgs.Feature) string { return formatTsunami(y.Properties.Tsunami) }},
+	columnAlert:       {"alert", "Alert", func(y usgs.Feature) string { return y.Properties.Alert }},
+	columnIDs:         {"ids", "Properties/IDs", func(y usgs.Feature) string { return y.Properties.Ids }},
+	columnFelt:        {"felt", "Felt", func(y usgs.Feature) string { return formatFelt(y.Properties.Felt) }},
+	columnSig:         {"sig", "Sig", func(y usgs.Feature) string { return fmt.Sprint(y.Properties.Sig) }},
+	columnMagType:     {"magtype", "Mag Type", func(y usgs.Feature) string { return formatMagType(y.Properties.MagType) }},
+	columnStatus:      {"status", "Status", func(y usgs.Feature) string { return y.Properties.Status }},
+	columnNetwork:     {"net", "Net", func(y usgs.Feature) string { return y.Properties.Net }},
+	columnNst:         {"nst", "Stations", func(y usgs.Feature) string { return formatStations(y.Properties.Nst) }},
+	columnGap:         {"gap", "Gap", func(y usgs.Feature) string { return formatGap(y.Properties.Gap) }},
+	columnRms:         {"rms", "RMS", func(y usgs.Feature) string { return formatRms(y.Properties.Rms) }},
+	columnDmin:        {"dmin", "Dmin", func(y usgs.Feature) string { return formatDmin(y.Properties.Dmin) }},
+	columnSource:      {"source", "Source", func(y usgs.Feature) string { return strings.ToUpper(y.Source) }},
+	columnReviewed:    {"reviewed", "R", func(y usgs.Feature) string { return formatReviewed(y.Properties.Status) }},
+	columnLocalTime:   {"localtime", "Epicenter Time", func(y usgs.Feature) string { return formatEpicenterTime(y, "15:04 MST") }},
+	columnUpdated:     {"updated", "Updated", nil},
+	columnCoordinates: {"coords", "Coordinates", nil},
+}
+
+// Columns shown when -columns isn't given, distance is added if there's a home to measure from
+var defaultColumns = []int{columnID, columnTime, columnMagnitude, columnDepth, columnLocation, columnTsunami, columnReviewed, columnAlert, columnFelt, columnIDs}
+
+// Columns -expert adds, for judging how good a solution is
+var expertColumns = []int{columnNst, columnGap, columnRms, columnDmin}
+
+// Add the expert columns to the end of columns, skipping any that are already there
+func withExpertColumns(columns []int) []int {
+	for _, expert := range expertColumns {
+		found := false
+		for _, column := range columns {
+			if column == expert {
+				found = true
+				break
+			}
+		}
+		if !found {
+			columns = append(columns, expert)
+		}
+	}
+
+	return columns
+}
+
+// Get the text for the magnitude type, eg: mww or ml
+// The feeds mix cases, eg: Mww and mww, so it's always lowercased to make them easy to compare
+func formatMagType(magType string) string {
+	return strings.ToLower(magType)
+}
+
+// Get the time of the quake at the epicenter, to tell whether it hit in the middle of the night
+// USGS gives the offset in minutes, but often leaves it out of automatic solutions so those are in UTC
+func epicenterTime(y usgs.Feature) (time.Time, bool) {
+	t := fromMillis(y.Properties.Time)
+	if y.Properties.Tz == nil || *y.Properties.Tz == 0 {
+		return t.UTC(), false
+	}
+
+	// Named like UTC+09:00 since we don't know the real zone's abbreviation
+	minutes := int(*y.Properties.Tz)
+	name := fmt.Sprintf("UTC+%02d:%02d", minutes/60, minutes%60)
+	if minutes < 0 {
+		name = fmt.Sprintf("UTC-%02d:%02d", -minutes/60, -minutes%60)
+	}
+
+	return t.In(time.FixedZone(name, minutes*60)), true
+}
+
+// Get the text for the time at the epicenter in the given layout
+func formatEpicenterTime(y usgs.Feature, layout string) string {
+	t, _ := epicenterTime(y)
+	return t.Format(layout)
+}
+
+// Get the text for the number of stations used to locate the quake
+// Like the other solution quality values it's 0 when USGS doesn't have it, which shows as -
+func formatStations(nst int) string {
+	if nst == 0 {
+		return "-"
+	}
+
+	return fmt.Sprint(nst)
+}
+
+// Get the text for the largest azimuthal gap between stations, in degrees
+func formatGap(gap float64) string {
+	if gap == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f°", gap)
+}
+
+// Get the text for the RMS travel time residual, in seconds
+func formatRms(rms float64) string {
+	if rms == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.2f s", rms)
+}
+
+// Get the text for the distance to the nearest station, in degrees
+func formatDmin(dmin float64) string {
+	if dmin == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.3f°", dmin)
+}
+
+// Get the text for the felt column, blank until someone has reported feeling the quake
+func formatFelt(felt int64) string {
+	if felt == 0 {
+		return ""
+	}
+
+	return fmt.Sprint(felt)
+}
+
+// Get the text for the reviewed column, R once a seismologist has looked at the quake and A
+// while it's still an automatic solution
+func formatReviewed(status string) string {
+	switch status {
+	case "reviewed":
+		return "R"
+	case "automatic":
+		return "A"
+	}
+
+	return ""
+}
+
+// Get the text for each cell of a quake's row
+func formatRow(y usgs.Feature) []string {
+	cells := make([]string, len(tableColumns))
+	for i, column := range tableColumns {
+		if column.text != nil {
+			cells[i] = column.text(y)
+		}
+	}
+
+	return cells
+}
+
+// Parse a comma separated list of column names into the columns to show, in order
+func parseColumns(value string, haveHome bool) ([]int, error) {
+	if value == "" {
+		columns := append([]int{}, defaultColumns...)
+		if haveHome {
+			// Straight after depth
+			columns = append(columns[:4], append([]int{columnDistance}, columns[4:]...)...)
+		}
+		return columns, nil
+	}
+
+	var names []string
+	byName := make(map[string]int, len(tableColumns))
+	for i, column := range tableColumns {
+		names = append(names, column.name)
+		byName[column.name] = i
+	}
+
+	var columns []int
+	for _, name := range strings.Split(value, ",") {
+		column, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("invalid column %q: must be one of %s", name, strings.Join(names, ", "))
+		}
+		if column == columnDistance && !haveHome {
+			return nil, fmt.Errorf("the distance column needs -home-lat and -home-lon")
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEpicenterTime(t *testing.T) {
+	at := millis(time.Date(2021, 3, 1, 18, 30, 0, 0, time.UTC))
+	offset := func(minutes int64) *int64 { return &minutes }
+
+	tests := []struct {
+		name   string
+		tz     *int64
+		want   string // In the column's layout
+		known  bool
+		detail string // In the detail pane
+	}{
+		{"japan", offset(540), "03:30 UTC+09:00", true, "Mar/02/03:30:00 +09:00 local at epicenter"},
+		{"india", offset(330), "00:00 UTC+05:30", true, "Mar/02/00:00:00 +05:30 local at epicenter"},
+		{"alaska", offset(-540), "09:30 UTC-09:00", true, "Mar/01/09:30:00 -09:00 local at epicenter"},
+		{"newfoundland", offset(-210), "15:00 UTC-03:30", true, "Mar/01/15:00:00 -03:30 local at epicenter"},
+		{"missing", nil, "18:30 UTC", false, "Mar/01/18:30:00 UTC, the epicenter's offset isn't known"},
+		{"zero", offset(0), "18:30 UTC", false, "Mar/01/18:30:00 UTC, the epicenter's offset isn't known"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quake := testQuake("a", 4, at)
+			quake.Properties.Tz = test.tz
+
+			local, known := epicenterTime(quake)
+			if known != test.known {
+				t.Errorf("known = %v, want %v", known, test.known)
+			}
+			if !local.Equal(time.Unix(0, at*int64(time.Millisecond))) {
+				t.Errorf("epicenter time %v is a different moment from the quake", local)
+			}
+			if got := tableColumns[columnLocalTime].text(quake); got != test.want {
+				t.Errorf("column = %q, want %q", got, test.want)
+			}
+			if details := formatDetails(quake, time.UTC); !strings.Contains(details, test.detail) {
+				t.Errorf("details don't have %q:\n%s", test.detail, details)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"         // Needed to read the config file line by line
+	"flag"          // Needed to set flags from the config file
+	"fmt"           // Needed for printing
+	"io"            // Needed to read and write configs
+	"os"            // Needed to open the config file
+	"path/filepath" // Needed to build the config file path
+	"strconv"       // Needed to quote and unquote values
+	"strings"       // Needed to parse lines
+	"time"          // Needed to write durations
+)
+
+// Where the config file lives under the user's config directory
+const CONFIGFILE = "earthquakecli/config.toml"
+
+// Flags that don't make sense in a config file
+var configSkipped = map[string]bool{
+	"config":       true,
+	"write-config": true,
+	"notify-test":  true,
+}
+
+// A key and value from a config file
+type configValue struct {
+	key   string
+	value string
+	line  int
+}
+
+// Get the default config file path, eg: ~/.config/earthquakecli/config.toml
+func defaultConfigPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, CONFIGFILE), nil
+}
+
+// Set flags from a config file, flags given on the command line win over the file
+// Keys are the flag names, eg: refresh = "30s"
+// A missing file is only an error if it was asked for with -config
+// Unknown keys are warned about so a typo doesn't stop the app starting
+func applyConfig(path string, explicit bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	values, err := readConfig(file)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	fromCommandLine := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		fromCommandLine[f.Name] = true
+	})
+
+	for _, v := range values {
+		if flag.Lookup(v.key) == nil || configSkipped[v.key] {
+			fmt.Fprintf(os.Stderr, "%s line %d: ignoring unknown key %q\n", path, v.line, v.key)
+			continue
+		}
+		if fromCommandLine[v.key] {
+			continue
+		}
+		if err := flag.Set(v.key, v.value); err != nil {
+			return fmt.Errorf("%s line %d: invalid value for %s: %v", path, v.line, v.key, err)
+		}
+	}
+
+	return nil
+}
+
+// Read the keys and values from a config file
+// This understands the simple part of TOML: key = value lines, quoted strings, and # comments
+func readConfig(r io.Reader) ([]configValue, error) {
+	var values []configValue
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+
+		value, err := parseConfigValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		values = append(values, configValue{
+			key:   strings.TrimSpace(parts[0]),
+			value: value,
+			line:  line,
+		})
+	}
+
+	return values, scanner.Err()
+}
+
+// Parse a config value, either a quoted string or a bare number or bool, with an optional comment after it
+func parseConfigValue(text string) (string, error) {
+	if text == "" {
+		return "", fmt.Errorf("missing value")
+	}
+
+	quote := text[0]
+	if quote != '"' && quote != '\'' {
+		if comment := strings.Index(text, "#"); comment >= 0 {
+			text = text[:comment]
+		}
+		return strings.TrimSpace(text), nil
+	}
+
+	// Find the closing quote, skipping escaped ones in double quoted strings
+	for i := 1; i < len(text); i++ {
+		if quote == '"' && text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] != quote {
+			continue
+		}
+
+		rest := strings.TrimSpace(text[i+1:])
+		if rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected text after %s", text[:i+1])
+		}
+
+		// Single quoted strings are literal in TOML
+		if quote == '\'' {
+			return text[1:i], nil
+		}
+		return strconv.Unquote(text[:i+1])
+	}
+
+	return "", fmt.Errorf("missing closing quote")
+}
+
+// Write the current settings as a config file
+// Settings left at their defaults are commented out so changing a default later still takes effect
+func writeConfig(w io.Writer) error {
+	var err error
+	write := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	write("# QuakeCLI config, command line flags override these\n")
+	flag.VisitAll(func(f *flag.Flag) {
+		if configSkipped[f.Name] {
+			return
+		}
+
+		value := f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			switch v := getter.Get().(type) {
+			case string:
+				value = strconv.Quote(v)
+			case time.Duration:
+				value = strconv.Quote(v.String())
+			}
+		}
+
+		prefix := ""
+		if f.Value.String() == f.DefValue {
+			prefix = "# "
+		}
+
+		write("\n# %s\n%s%s = %s\n", f.Usage, prefix, f.Name, value)
+	})
+
+	return err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"             // Needed to build each line before writing it
+	"context"           // Needed to wrap sources
+	"fmt"               // Needed to format the values
+	"io"                // Needed to wrap response bodies
+	"net/http"          // Needed to wrap the transport
+	"net/http/httputil" // Needed to dump headers for -debug-http
+	"os"                // Needed to open the log
+	"strconv"           // Needed to quote values
+	"strings"           // Needed to spot values that need quoting
+	"sync"              // Needed to share the log between goroutines
+	"time"              // Needed to time requests and stamp each line
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Logs what fetching is up to, for working out why the table stopped updating
+// Each line is a message and key=value pairs, eg: time=... msg=fetch features=212 duration=1.3s
+// It only ever writes to its file so it can't mess up the TUI, it's safe to use from more than one
+// goroutine, and a nil *debugLog ignores everything
+type debugLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	headers bool  // Dump request and response headers too
+	err     error // The first write error, reported when the log is closed
+}
+
+// Open a debug log for appending, creating it if needed
+func openDebugLog(path string, headers bool) (*debugLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &debugLog{file: file, headers: headers}, nil
+}
+
+// Write a line with a message and key value pairs
+func (d *debugLog) log(msg string, keyvals ...interface{}) {
+	if d == nil {
+		return
+	}
+
+	var line bytes.Buffer
+	fmt.Fprintf(&line, "time=%s msg=%s", time.Now().UTC().Format(time.RFC3339Nano), debugValue(msg))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&line, " %v=%s", keyvals[i], debugValue(keyvals[i+1]))
+	}
+	line.WriteByte('\n')
+
+	// Each line goes out in one write so nothing is lost if we're killed
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.file.Write(line.Bytes()); err != nil && d.err == nil {
+		d.err = err
+	}
+}
+
+// Format a value for the log, quoting it if it's empty or has spaces, quotes, or equals signs in it
+func debugValue(value interface{}) string {
+	text := fmt.Sprint(value)
+	if err, ok := value.(error); ok {
+		text = describeFetchError(err)
+	}
+	if text == "" || strings.ContainsAny(text, " \t\r\n\"=") {
+		return strconv.Quote(text)
+	}
+
+	return text
+}
+
+// Wrap a source so every fetch is logged with how long it took and how many quakes came back
+func (d *debugLog) trace(source quakeSource) quakeSource {
+	if d == nil {
+		return source
+	}
+
+	return func(ctx context.Context) (usgs.Feed, error) {
+		started := time.Now()
+		feed, err := source(ctx)
+
+		switch err {
+		case nil:
+			d.log("fetch", "features", len(feed.Features), "generated", feed.Metadata.Generated, "duration", time.Since(started))
+			for _, warning := range feed.Warnings {
+				d.log("feed warning", "warning", warning)
+			}
+		case usgs.ErrNotModified:
+			d.log("fetch", "not_modified", true, "duration", time.Since(started))
+		default:
+			d.log("fetch failed", "error", err, "duration", time.Since(started))
+		}
+
+		return feed, err
+	}
+}
+
+// Wrap the transport of an http.Client so every request is logged
+func (d *debugLog) wrap(client *http.Client) {
+	if d == nil {
+		return
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &debugTransport{base: base, log: d}
+}
+
+// Logs each request, its response, and how much of the body was read
+type debugTransport struct {
+	base http.RoundTripper
+	log  *debugLog
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.log.headers {
+		if dump, err := httputil.DumpRequestOut(req, false); err == nil {
+			t.log.log("http request", "url", req.URL, "headers", string(dump))
+		}
+	}
+
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.log.log("http failed", "method", req.Method, "url", req.URL, "error", err, "duration", time.Since(started))
+		return resp, err
+	}
+
+	t.log.log("http", "method", req.Method, "url", req.URL, "status", resp.StatusCode, "length", resp.ContentLength, "duration", time.Since(started))
+	if t.log.headers {
+		if dump, err := httputil.DumpResponse(resp, false); err == nil {
+			t.log.log("http response", "url", req.URL, "headers", string(dump))
+		}
+	}
+	resp.Body = &debugBody{ReadCloser: resp.Body, log: t.log, url: req.URL.String(), started: started}
+
+	return resp, nil
+}
+
+// A response body that logs how much was read from it once it's closed
+type debugBody struct {
+	io.ReadCloser
+	log     *debugLog
+	url     string
+	started time.Time
+	bytes   int64
+	err     error
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	if b.err != nil {
+		b.log.log("http body", "url", b.url, "bytes", b.bytes, "error", b.err, "duration", time.Since(b.started))
+	} else {
+		b.log.log("http body", "url", b.url, "bytes", b.bytes, "duration", time.Since(b.started))
+	}
+
+	return b.ReadCloser.Close()
+}
+
+// Flush and close the log, returning the first error writing it
+func (d *debugLog) Close() error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err := d.file.Sync()
+	if closeErr := d.file.Close(); err == nil {
+		err = closeErr
+	}
+	if d.err != nil {
+		return d.err
+	}
+
+	return err
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context" // Needed to cancel fetches on shutdown
+	"errors"  // Needed for quakes without a detail feed
+	"fmt"     // Needed for printing
+	"sort"    // Needed to list the products in order
+	"strings" // Needed to build the details text
+	"time"    // Needed for the epicenter's time zone
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/rivo/tview"
+)
+
+// The detail pane beside the table, showing everything about the selected quake
+// along with anything fetched from its detail feed
+// Only used from the tview goroutine, the fetches hand their results back with QueueUpdateDraw
+type detailPane struct {
+	view     *tview.TextView
+	quakes   *quakeTable
+	app      *tview.Application
+	client   *usgs.Client
+	location *time.Location
+	shownID  string // The quake being shown, so the scroll position is kept while it's updated
+
+	// Detail feeds we've fetched, fetches that failed, and fetches in flight, by event ID
+	fetched map[string]usgs.Detail
+	failed  map[string]error
+	loading map[string]bool
+}
+
+func newDetailPane(view *tview.TextView, quakes *quakeTable, app *tview.Application, client *usgs.Client, location *time.Location) *detailPane {
+	return &detailPane{
+		view:     view,
+		quakes:   quakes,
+		app:      app,
+		client:   client,
+		location: location,
+		fetched:  make(map[string]usgs.Detail),
+		failed:   make(map[string]error),
+		loading:  make(map[string]bool),
+	}
+}
+
+// Get the quake on the currently selected row
+func (d *detailPane) selected() (usgs.Feature, bool) {
+	row, _ := d.quakes.table.GetSelection()
+	quake, ok := d.quakes.table.GetCell(row, 0).Reference.(usgs.Feature)
+
+	return quake, ok
+}
+
+// Show the details of the quake on the currently selected row
+func (d *detailPane) show() {
+	quake, ok := d.selected()
+	if !ok {
+		d.view.Clear()
+		return
+	}
+
+	text := formatDetails(quake, d.location)
+	if expiry := d.quakes.expiryText(quake); expiry != "" {
+		text += fmt.Sprintf("[yellow]%-10s[white] %s\n", "Expires:", expiry)
+	}
+	text += formatRevisions(d.quakes.revisionsFor(quake.ID), d.location)
+	switch detail, fetched := d.fetched[quake.ID]; {
+	case fetched:
+		text += "\n" + formatDetailFeed(detail)
+	case d.loading[quake.ID]:
+		text += "\n[yellow]Fetching more details...[white]\n"
+	case d.failed[quake.ID] != nil:
+		text += "\n[red]Couldn't fetch more details: " + tview.Escape(d.failed[quake.ID].Error()) + "[white]\n"
+	}
+
+	// Only go back to the top for another quake, the expiry countdown changes the text every minute
+	if d.view.GetText(false) != text {
+		d.view.SetText(text)
+		if quake.ID != d.shownID {
+			d.view.ScrollToBeginning()
+		}
+	}
+	d.shownID = quake.ID
+}
+
+// Fetch the detail feed for the selected quake in the background, unless we already have it
+// Failed fetches are tried again
+func (d *detailPane) fetch(ctx context.Context) {
+	quake, ok := d.selected()
+	if !ok {
+		return
+	}
+	if _, fetched := d.fetched[quake.ID]; fetched || d.loading[quake.ID] {
+		return
+	}
+
+	// The CSV feeds and other agencies don't link to a detail feed
+	if quake.Properties.Detail == "" {
+		d.failed[quake.ID] = errors.New("no detail feed for this quake")
+		d.show()
+		return
+	}
+
+	d.loading[quake.ID] = true
+	delete(d.failed, quake.ID)
+	d.show()
+
+	go func() {
+		detail, err := d.client.GetDetail(ctx, quake.Properties.Detail)
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.app.QueueUpdateDraw(func() {
+			delete(d.loading, quake.ID)
+			if err != nil {
+				d.failed[quake.ID] = err
+			} else {
+				d.fetched[quake.ID] = detail
+			}
+
+			// The selection may have moved on while we were fetching
+			d.show()
+		})
+	}()
+}
+
+// Format the magnitude changes we've seen this session, if there were any
+func formatRevisions(revisions []magnitudeRevision, location *time.Location) string {
+	if len(revisions) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	fmt.Fprint(&text, "\n[::b]Magnitude revisions[::-]\n")
+	for _, revision := range revisions {
+		at := revision.at.In(location).Format("Jan/02/15:04:05")
+		fmt.Fprintf(&text, "[yellow]%-10s[white] M%.02f → M%.02f (%+.02f)\n", at, revision.from, revision.to, revision.to-revision.from)
+	}
+
+	return text.String()
+}
+
+// Format the interesting parts of a detail feed: felt reports, intensities, and products
+func formatDetailFeed(detail usgs.Detail) string {
+	var details strings.Builder
+	p := detail.Properties
+
+	line := func(label string, value interface{}) {
+		fmt.Fprintf(&details, "[yellow]%-10s[white] %s\n", label+":", tview.Escape(fmt.Sprint(value)))
+	}
+	product := func(label, productType, name string) {
+		if value, ok := detail.ProductProperty(productType, name); ok {
+			line(label, value)
+		}
+	}
+
+	fmt.Fprint(&details, "[::b]Detail feed[::-]\n")
+	if p.Felt != nil {
+		line("Felt", fmt.Sprintf("%d reports", *p.Felt))
+	}
+	if p.Cdi != nil {
+		line("CDI", *p.Cdi)
+	}
+	if p.Mmi != nil {
+		line("MMI", *p.Mmi)
+	}
+	product("ShakeMap", "shakemap", "maxmmi")
+	product("DYFI MMI", "dyfi", "maxmmi")
+	product("Responses", "dyfi", "num-responses")
+
+	products := make([]string, 0, len(p.Products))
+	for productType := range p.Products {
+		products = append(products, productType)
+	}
+	sort.Strings(products)
+	line("Products", strings.Join(products, ", "))
+
+	return details.String()
+}
+
+// Format all the properties of a quake as labelled lines
+func formatDetails(quake usgs.Feature, location *time.Location) string {
+	var details strings.Builder
+	p := quake.Properties
+
+	line := func(label string, value interface{}) {
+		fmt.Fprintf(&details, "[yellow]%-10s[white] %s\n", label+":", tview.Escape(fmt.Sprint(value)))
+	}
+
+	fmt.Fprintf(&details, "[::b]%s[::-]\n\n", tview.Escape(p.Title))
+	if p.Tsunami == 1 {
+		fmt.Fprint(&details, "[white:darkblue] 🌊 Tsunami flag set, check tsunami.gov [-:-]\n\n")
+	}
+	line("ID", quake.ID)
+	if aliases := quakeAliases(quake); len(aliases) > 0 {
+		line("Also", strings.Join(aliases, ", ")+", from other networks")
+	}
+	line("Time", formatTime(p.Time, location))
+	if t, ok := epicenterTime(quake); ok {
+		line("Local", t.Format("Jan/02/15:04:05 -07:00")+" local at epicenter")
+	} else {
+		line("Local", t.Format("Jan/02/15:04:05")+" UTC, the epicenter's offset isn't known")
+	}
+	line("Updated", formatTime(p.Updated, location))
+	line("Magnitude", strings.TrimSpace(formatMagnitude(quake)+" "+formatMagType(p.MagType)))
+	line("Place", quakePlace(quake))
+	if point, ok := quakePoint(quake); ok {
+		line("Coords", formatDecimal(point))
+		line("DMS", formatDMS(point))
+	}
+	if _, ok := quakeDepth(quake); ok {
+		line("Depth", formatDepth(quake.Geometry)+" km")
+	} else {
+		line("Depth", formatDepth(quake.Geometry))
+	}
+	line("Type", p.Type)
+	line("Status", p.Status)
+	line("Alert", p.Alert)
+	line("Tsunami", p.Tsunami == 1)
+	line("Felt", p.Felt)
+	line("CDI", p.Cdi)
+	line("MMI", p.Mmi)
+	line("Sig", p.Sig)
+	line("Network", p.Net)
+	line("Code", p.Code)
+	line("IDs", p.Ids)
+	line("Sources", p.Sources)
+	line("Products", p.Types)
+	line("Stations", formatStations(p.Nst))
+	line("Dmin", formatDmin(p.Dmin))
+	line("RMS", formatRms(p.Rms))
+	line("Gap", formatGap(p.Gap))
+	line("URL", p.URL)
+
+	return details.String()
+}
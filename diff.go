@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context" // Needed to cancel the fetch when interrupted
+	"fmt"     // Needed for printing
+	"io"      // Needed to write the differences
+	"sort"    // Needed to print the quakes oldest first
+	"strings" // Needed to keep tabs out of the output
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Exit codes for the diff subcommand, so scripts can tell if there's anything to look at
+const (
+	DIFFUNCHANGED = 0
+	DIFFCHANGED   = 1
+	DIFFFAILED    = 2
+)
+
+// Run the diff subcommand, fetching the quakes once and printing what's changed since the state file
+// was last saved: new quakes, changed magnitudes, and quakes that are gone
+// The state file is saved afterwards so the next run only shows what's changed since this one
+func runDiff(ctx context.Context, w, errs io.Writer, source quakeSource, filter quakeFilter, alerts quakeAlerts, stateFile string) int {
+	if stateFile == "" {
+		fmt.Fprintln(errs, "invalid diff: there's no state file to compare against, give one with -state")
+		return DIFFFAILED
+	}
+
+	data, err := source(ctx)
+	if err != nil {
+		fmt.Fprintln(errs, "fetch failed:", describeFetchError(err))
+		return DIFFFAILED
+	}
+
+	quakeList := loadState(stateFile, filter)
+	before := make(map[string]usgs.Feature, len(quakeList))
+	for id, quake := range quakeList {
+		before[id] = quake
+	}
+
+	changes, _ := diffQuakes(data, quakeList, filter, alerts)
+	lines := diffLines(changes, before, quakeList, filter)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			fmt.Fprintln(errs, err)
+			return DIFFFAILED
+		}
+	}
+
+	if err := saveState(stateFile, quakeList); err != nil {
+		fmt.Fprintln(errs, "couldn't save state:", err)
+		return DIFFFAILED
+	}
+
+	if len(lines) == 0 {
+		return DIFFUNCHANGED
+	}
+
+	return DIFFCHANGED
+}
+
+// Get a line for each change worth telling about, new quakes first, then changed magnitudes,
+// then quakes that are gone, each oldest first
+// Updates that didn't change the magnitude, eg: more felt reports, are left out, and so are quakes
+// that only aged out or were merged into another network's solution, since nothing happened to them
+func diffLines(changes []quakeChange, before, quakeList map[string]usgs.Feature, filter quakeFilter) []string {
+	merged := make(map[string]bool)
+	for _, quake := range quakeList {
+		for _, id := range quakeAliases(quake) {
+			merged[id] = true
+		}
+	}
+
+	var added, changed, removed []quakeChange
+	for _, change := range changes {
+		switch change.action {
+		case changeNew:
+			added = append(added, change)
+		case changeUpdate:
+			previous, ok := before[change.quake.ID]
+			if change.replaces != "" {
+				previous, ok = before[change.replaces]
+			}
+			if ok && formatMagnitude(previous) != formatMagnitude(change.quake) {
+				changed = append(changed, change)
+			}
+		case changeRemove:
+			if !filter.tooOld(change.quake) && !merged[change.quake.ID] {
+				removed = append(removed, change)
+			}
+		}
+	}
+
+	var lines []string
+	for _, group := range [][]quakeChange{added, changed, removed} {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].quake.Properties.Time < group[j].quake.Properties.Time
+		})
+		for _, change := range group {
+			lines = append(lines, formatDiff(change, before))
+		}
+	}
+
+	return lines
+}
+
+// Format a change like a diff: + for a new quake, ~ for a changed magnitude, and - for a quake
+// that's gone, with why it's gone
+func formatDiff(change quakeChange, before map[string]usgs.Feature) string {
+	quake := change.quake
+	sign := "+"
+	magnitude := "M " + formatMagnitude(quake)
+	place := quakePlace(quake)
+	switch change.action {
+	case changeUpdate:
+		previous := before[change.quake.ID]
+		if change.replaces != "" {
+			previous = before[change.replaces]
+		}
+		sign = "~"
+		magnitude = fmt.Sprintf("M %s → %s", formatMagnitude(previous), formatMagnitude(quake))
+	case changeRemove:
+		sign = "-"
+		if quake.Properties.Status == "deleted" {
+			place += " (deleted)"
+		}
+	}
+
+	fields := []string{
+		quake.ID,
+		formatScriptTime(quake.Properties.Time),
+		magnitude,
+		place,
+	}
+	for i := range fields {
+		fields[i] = strings.Replace(fields[i], "\t", " ", -1)
+	}
+
+	return sign + " " + strings.Join(fields, "\t")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json" // Needed to read and write the dismissed quakes
+	"fmt"           // Needed to format the footer note
+	"io/ioutil"     // Needed to read the dismissed quakes
+	"os"            // Needed to move the file into place
+	"path/filepath" // Needed to create the state directory
+	"strings"       // Needed to name the file
+
+	"github.com/rivo/tview"
+)
+
+// Hide the selected quake, it stays hidden through refreshes until it's undone
+func (q *quakeTable) dismissSelected() {
+	id := q.selectedID()
+	if id == "" || q.dismissed[id] {
+		return
+	}
+
+	q.dismiss([]string{id})
+}
+
+// Hide every quake shown in the smallest magnitude band that's in the table, eg: all the M<2s
+// in a swarm, quakes pinned by a watch region and quakes without a magnitude are left alone
+func (q *quakeTable) dismissSmallest() {
+	smallest := len(magnitudeBands)
+	band := func(row quakeRow) int {
+		mag, ok := quakeMagnitude(row.quake)
+		if !ok || row.region != "" || q.dismissed[row.quake.ID] {
+			return len(magnitudeBands)
+		}
+		for b, band := range magnitudeBands {
+			if mag >= band.min && mag < band.max {
+				return b
+			}
+		}
+		return len(magnitudeBands)
+	}
+
+	for _, row := range q.shown {
+		if b := band(row); b < smallest {
+			smallest = b
+		}
+	}
+	if smallest == len(magnitudeBands) {
+		return
+	}
+
+	var ids []string
+	for _, row := range q.shown {
+		if band(row) == smallest {
+			ids = append(ids, row.quake.ID)
+		}
+	}
+	q.dismiss(ids)
+}
+
+// Hide some quakes, remembering them together so they can be brought back with one undo
+func (q *quakeTable) dismiss(ids []string) {
+	if q.dismissed == nil {
+		q.dismissed = make(map[string]bool)
+	}
+	for _, id := range ids {
+		q.dismissed[id] = true
+	}
+	q.dismissals = append(q.dismissals, ids)
+
+	// The quake after the dismissed one moves up into the selection
+	q.rerender()
+}
+
+// Bring back the last quakes that were dismissed
+func (q *quakeTable) undoDismiss() {
+	if len(q.dismissals) == 0 {
+		return
+	}
+
+	last := q.dismissals[len(q.dismissals)-1]
+	q.dismissals = q.dismissals[:len(q.dismissals)-1]
+	for _, id := range last {
+		delete(q.dismissed, id)
+	}
+
+	q.rerender()
+	q.selectID(last[0])
+}
+
+// Show or hide the dismissed quakes, they're greyed out while they're shown
+func (q *quakeTable) toggleDismissed() {
+	q.revealDismissed = !q.revealDismissed
+	q.rerender()
+}
+
+// Redraw the table keeping the selection where it was
+func (q *quakeTable) rerender() {
+	selectedID := q.selectedID()
+	q.render()
+	q.selectID(selectedID)
+}
+
+// Describe what's being hidden for the footer, eg: M≥3.5 hides 40 · Filter mag>4 hides 12 ·
+// 30 dismissed (v shows) · or nothing if nothing is
+func (q *quakeTable) footerNote() string {
+	note := ""
+	if q.minMag > q.fetchMinMag {
+		note += fmt.Sprintf("[yellow]M≥%.1f hides %d (+/-)[-] · ", q.minMag, q.belowMinMag)
+	}
+	if q.filter != nil {
+		note += fmt.Sprintf("[yellow]Filter %s hides %d[-] · ", tview.Escape(q.filterText), q.filtered)
+	}
+
+	dismissed := 0
+	for id := range q.dismissed {
+		if _, ok := q.events.get(id); ok {
+			dismissed++
+		}
+	}
+	switch {
+	case dismissed > 0 && q.revealDismissed:
+		note += fmt.Sprintf("%d dismissed (v hides) · ", dismissed)
+	case dismissed > 0:
+		note += fmt.Sprintf("%d dismissed (v shows) · ", dismissed)
+	}
+
+	return note
+}
+
+// Get the file dismissed quakes are remembered in, alongside the state file for the feed
+func dismissedPath(stateFile string) string {
+	if stateFile == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(stateFile, ".json") + "-dismissed.json"
+}
+
+// Load the quakes that were dismissed last time
+// Like the state file, a missing or corrupt file just means starting from scratch
+func loadDismissed(path string) map[string]bool {
+	dismissed := make(map[string]bool)
+	if path == "" {
+		return dismissed
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return dismissed
+	}
+
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return dismissed
+	}
+	for _, id := range ids {
+		dismissed[id] = true
+	}
+
+	return dismissed
+}
+
+// Save the dismissed quakes that are still in the table, so the file doesn't keep growing with
+// quakes that have aged out of the feed
+func (q *quakeTable) saveDismissed(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	ids := []string{}
+	for _, row := range q.events.snapshot() {
+		if q.dismissed[row.quake.ID] {
+			ids = append(ids, row.quake.ID)
+		}
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp := path + ".tmp"
+	if err := ioutil.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, path)
+}
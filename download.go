@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"         // Needed to format sizes
+	"io"          // Needed to wrap response bodies
+	"net/http"    // Needed to wrap the transport
+	"sync/atomic" // Needed to share the count between goroutines
+)
+
+// Counts the bytes that come over the network, before they're decompressed, so it's clear how
+// much gzip is saving on a metered connection
+// It's safe to use from more than one goroutine
+type countingTransport struct {
+	base  http.RoundTripper
+	bytes int64 // Only used with atomic
+}
+
+// Wrap the transport of an http.Client so everything it downloads is counted
+func countDownloads(client *http.Client) *countingTransport {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport := &countingTransport{base: base}
+	client.Transport = transport
+
+	return transport
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Body = &countingBody{ReadCloser: resp.Body, bytes: &t.bytes}
+
+	return resp, nil
+}
+
+// Get how many bytes have been downloaded so far
+func (t *countingTransport) downloaded() int64 {
+	return atomic.LoadInt64(&t.bytes)
+}
+
+// A response body that adds what's read from it to a count
+type countingBody struct {
+	io.ReadCloser
+	bytes *int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.bytes, int64(n))
+
+	return n, err
+}
+
+// Format a number of bytes, eg: 1.4 MB
+func formatBytes(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value := float64(bytes)
+	for _, suffix := range []string{"kB", "MB", "GB"} {
+		value /= unit
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+
+	return fmt.Sprintf("%.1f TB", value/unit)
+}
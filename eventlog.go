@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"         // Needed to build each line before writing it
+	"encoding/csv"  // Needed to write CSV lines
+	"encoding/json" // Needed to write JSON lines
+	"fmt"           // Needed for errors
+	"io"            // Needed to check the end of the log
+	"os"            // Needed to open the log
+	"sync"          // Needed to share the log between goroutines
+	"time"          // Needed to format the revision time
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// A quake as it's written to the event log, each revision gets its own line
+type loggedEvent struct {
+	exportedQuake
+	Updated string `json:"updated"`
+}
+
+// Header row for CSV event logs, in the same order as loggedEvent
+var eventLogHeader = append(append([]string{}, exportHeader...), "updated")
+
+// Appends every new or updated quake we see to a file, as JSON lines or CSV
+// It's safe to use from more than one goroutine
+type eventLog struct {
+	mu     sync.Mutex
+	file   *os.File
+	format string
+	err    error // The first write error, reported when the log is closed
+}
+
+// Open an event log for appending, creating it if needed
+// A line left half written by being killed is ended so the next line starts cleanly
+func openEventLog(path, format string) (*eventLog, error) {
+	if format != "jsonl" && format != "csv" {
+		return nil, fmt.Errorf("invalid log format %q: must be jsonl or csv", format)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &eventLog{file: file, format: format}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	switch {
+	case size == 0 && format == "csv":
+		err = l.write(eventLogHeader)
+	case size > 0:
+		last := make([]byte, 1)
+		if _, err = file.ReadAt(last, size-1); err == nil && last[0] != '\n' {
+			_, err = file.Write([]byte("\n"))
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Log a quake that's new or was updated
+// Errors are kept until the log is closed since there's nowhere to show them while the TUI is up
+func (l *eventLog) log(quake usgs.Feature) {
+	if l == nil {
+		return
+	}
+
+	event := loggedEvent{
+		exportedQuake: exportQuake(quake),
+		Updated:       fromMillis(quake.Properties.Updated).UTC().Format(time.RFC3339),
+	}
+
+	var err error
+	if l.format == "csv" {
+		err = l.write(append(csvRecord(event.exportedQuake), event.Updated))
+	} else {
+		err = l.write(event)
+	}
+
+	if err != nil {
+		l.mu.Lock()
+		if l.err == nil {
+			l.err = err
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Write a CSV record or JSON value as one line
+// Lines are written with a single call so a kill can't leave part of one line mixed into another
+func (l *eventLog) write(v interface{}) error {
+	var line bytes.Buffer
+	if record, ok := v.([]string); ok {
+		w := csv.NewWriter(&line)
+		w.Write(record)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	} else if err := json.NewEncoder(&line).Encode(v); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.file.Write(line.Bytes())
+
+	return err
+}
+
+// Flush the log to disk and close it, returning the first error we hit while logging
+func (l *eventLog) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.err
+	if syncErr := l.file.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
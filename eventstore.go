@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sort" // Needed to find where a quake goes
+)
+
+// The quakes in the table, newest first, with an index by ID so a quake can be found or replaced
+// without looking through all of them
+// It knows nothing about drawing, the table sorts a snapshot of it however it's sorted and renders that
+// Like the rest of the table it's only used from the tview event loop, see quakeStore for other goroutines
+type eventStore struct {
+	rows  []quakeRow     // Newest first, quakes at the same time by ID
+	index map[string]int // Where each quake is in rows
+}
+
+// Check if a row goes before another, newest first
+func storedBefore(a, b quakeRow) bool {
+	if a.quake.Properties.Time != b.quake.Properties.Time {
+		return a.quake.Properties.Time > b.quake.Properties.Time
+	}
+
+	return a.quake.ID < b.quake.ID
+}
+
+// Add a quake, or replace it if it's already there, returning the row it replaced
+// An update that didn't change the quake's time is replaced where it is
+func (s *eventStore) upsert(row quakeRow) (quakeRow, bool) {
+	if s.index == nil {
+		s.index = make(map[string]int)
+	}
+
+	i, ok := s.index[row.quake.ID]
+	if ok {
+		previous := s.rows[i]
+		if previous.quake.Properties.Time == row.quake.Properties.Time {
+			s.rows[i] = row
+			return previous, true
+		}
+		s.remove(row.quake.ID)
+		s.insert(row)
+		return previous, true
+	}
+
+	s.insert(row)
+	return quakeRow{}, false
+}
+
+// Put a quake that isn't in the store yet where it belongs
+func (s *eventStore) insert(row quakeRow) {
+	i := sort.Search(len(s.rows), func(i int) bool {
+		return storedBefore(row, s.rows[i])
+	})
+
+	s.rows = append(s.rows, quakeRow{})
+	copy(s.rows[i+1:], s.rows[i:])
+	s.rows[i] = row
+	s.reindex(i)
+}
+
+// Take a quake out, returning its row if it was there
+func (s *eventStore) remove(id string) (quakeRow, bool) {
+	i, ok := s.index[id]
+	if !ok {
+		return quakeRow{}, false
+	}
+
+	row := s.rows[i]
+	s.rows = append(s.rows[:i], s.rows[i+1:]...)
+	delete(s.index, id)
+	s.reindex(i)
+
+	return row, true
+}
+
+// Fix the index for everything from position i on, after rows were moved
+func (s *eventStore) reindex(i int) {
+	for ; i < len(s.rows); i++ {
+		s.index[s.rows[i].quake.ID] = i
+	}
+}
+
+// Get a quake's row by ID
+func (s *eventStore) get(id string) (quakeRow, bool) {
+	i, ok := s.index[id]
+	if !ok {
+		return quakeRow{}, false
+	}
+
+	return s.rows[i], true
+}
+
+// Get a copy of every row, newest first, that can be sorted without upsetting the store
+func (s *eventStore) snapshot() []quakeRow {
+	return append([]quakeRow(nil), s.rows...)
+}
+
+// Get how many quakes there are
+func (s *eventStore) len() int {
+	return len(s.rows)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Make a row for the store with just what it orders by and a magnitude to tell updates apart
+func storedRow(id string, mag float64, millis int64) quakeRow {
+	return quakeRow{quake: usgs.Feature{ID: id, Properties: usgs.Properties{Mag: &mag, Time: millis}}}
+}
+
+// Get the IDs in a store, in order, checking the index agrees with them
+func storedIDs(t *testing.T, s *eventStore) []string {
+	t.Helper()
+
+	var ids []string
+	for i, row := range s.snapshot() {
+		ids = append(ids, row.quake.ID)
+		if s.index[row.quake.ID] != i {
+			t.Errorf("index has %s at %d, it's at %d", row.quake.ID, s.index[row.quake.ID], i)
+		}
+	}
+	if len(s.index) != s.len() {
+		t.Errorf("index has %d quakes, store has %d", len(s.index), s.len())
+	}
+
+	return ids
+}
+
+func TestEventStoreUpsertOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		quakes []quakeRow
+		want   []string
+	}{
+		{"newest first", []quakeRow{storedRow("a", 1, 1000), storedRow("b", 1, 3000), storedRow("c", 1, 2000)}, []string{"b", "c", "a"}},
+		{"already in order", []quakeRow{storedRow("c", 1, 3000), storedRow("b", 1, 2000), storedRow("a", 1, 1000)}, []string{"c", "b", "a"}},
+		{"same time by ID", []quakeRow{storedRow("z", 1, 1000), storedRow("m", 1, 1000), storedRow("a", 1, 1000)}, []string{"a", "m", "z"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var s eventStore
+			for _, row := range test.quakes {
+				if _, replaced := s.upsert(row); replaced {
+					t.Errorf("upsert(%s) replaced a quake, want it added", row.quake.ID)
+				}
+			}
+			if got := storedIDs(t, &s); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("store = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEventStoreUpdateInPlace(t *testing.T) {
+	var s eventStore
+	for _, id := range []string{"a", "b", "c"} {
+		s.upsert(storedRow(id, 1, int64(id[0])*1000))
+	}
+
+	// A new magnitude keeps its place
+	previous, replaced := s.upsert(storedRow("b", 2.5, int64('b')*1000))
+	if !replaced {
+		t.Fatal("upsert didn't replace b")
+	}
+	if *previous.quake.Properties.Mag != 1 {
+		t.Errorf("replaced row has magnitude %v, want 1", *previous.quake.Properties.Mag)
+	}
+	if got, want := storedIDs(t, &s), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("store = %v, want %v", got, want)
+	}
+	if row, _ := s.get("b"); *row.quake.Properties.Mag != 2.5 {
+		t.Errorf("b wasn't updated: %+v", row.quake.Properties)
+	}
+
+	// A new time moves it
+	s.upsert(storedRow("b", 2.5, int64('z')*1000))
+	if got, want := storedIDs(t, &s), []string{"b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("store after b's time changed = %v, want %v", got, want)
+	}
+}
+
+func TestEventStoreRemove(t *testing.T) {
+	var s eventStore
+	for _, id := range []string{"a", "b", "c", "d"} {
+		s.upsert(storedRow(id, 1, int64(id[0])*1000))
+	}
+
+	if _, ok := s.remove("c"); !ok {
+		t.Error("remove(c) didn't find it")
+	}
+	if _, ok := s.remove("c"); ok {
+		t.Error("remove(c) found it again")
+	}
+	if _, ok := s.remove("nope"); ok {
+		t.Error("remove found a quake that was never there")
+	}
+	if got, want := storedIDs(t, &s), []string{"d", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("store = %v, want %v", got, want)
+	}
+	if _, ok := s.get("c"); ok {
+		t.Error("get found c after it was removed")
+	}
+}
+
+func TestEventStoreSnapshotIsACopy(t *testing.T) {
+	var s eventStore
+	s.upsert(storedRow("a", 1, 1000))
+	s.upsert(storedRow("b", 1, 2000))
+
+	snapshot := s.snapshot()
+	snapshot[0], snapshot[1] = snapshot[1], snapshot[0]
+	if got, want := storedIDs(t, &s), []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("store = %v after sorting a snapshot, want %v", got, want)
+	}
+}
+
+// Apply a refresh's worth of changes to a table that already has a day of quakes in it, the
+// way apply does, and sort it for the table
+func BenchmarkEventStoreRefreshAllDay(b *testing.B) {
+	feed := allDayFeed()
+	q := &quakeTable{sortBy: sortTime}
+	for _, quake := range feed.Features {
+		q.events.upsert(quakeRow{quake: quake})
+	}
+
+	// A refresh brings a few new quakes and updates to some of the others
+	var batch []quakeRow
+	for i, quake := range feed.Features {
+		if i%10 == 0 {
+			quake.Properties.Updated++
+			batch = append(batch, quakeRow{quake: quake})
+		}
+	}
+	for i := 0; i < 20; i++ {
+		batch = append(batch, quakeRow{quake: testQuake(fmt.Sprintf("new%02d", i), 2, feed.Metadata.Generated+int64(i))})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, row := range batch {
+			q.events.upsert(row)
+		}
+		q.sorted()
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"  // Needed to format the countdown
+	"time" // Needed to work out when quakes age out
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Rows are dimmed once they're this close to aging out of the table
+const EXPIRINGSOON = 5 * time.Minute
+
+// Get how long until a quake ages out of the table, if quakes age out at all
+// It's gone at the first fetch after this, so it can stay a little longer
+// Ages are measured by the feed's clock like the filter does, so a clock that's off doesn't
+// count down to a time the quake won't actually go at
+func (q *quakeTable) expiresIn(quake usgs.Feature) (time.Duration, bool) {
+	if q.maxAge <= 0 {
+		return 0, false
+	}
+
+	return fromMillis(quake.Properties.Time).Add(q.maxAge).Sub(q.feedNow()), true
+}
+
+// Get what time the feed thinks it is, going by how far ahead of ours its clock was last time
+func (q *quakeTable) feedNow() time.Time {
+	return time.Now().Add(q.feedSkew)
+}
+
+// Check if a row is about to age out, so it can be dimmed
+// Rows that aren't yet get a redraw booked for when they are, like a highlight running out
+func (q *quakeTable) expiringSoon(row quakeRow) bool {
+	left, ok := q.expiresIn(row.quake)
+	if !ok || row.region != "" {
+		return false
+	}
+
+	if left > EXPIRINGSOON {
+		at := time.Now().Add(left - EXPIRINGSOON)
+		if q.nextFade.IsZero() || at.Before(q.nextFade) {
+			q.nextFade = at
+		}
+		return false
+	}
+
+	return true
+}
+
+// Describe when a quake ages out for the detail pane, eg: "in 3m", or nothing if quakes are kept
+func (q *quakeTable) expiryText(quake usgs.Feature) string {
+	left, ok := q.expiresIn(quake)
+	if !ok {
+		return ""
+	}
+	if left <= 0 {
+		return "at the next refresh"
+	}
+
+	return "in " + formatCountdown(left)
+}
+
+// Format how long is left of something, eg: "1h 12m"
+func formatCountdown(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+
+	return fmt.Sprintf("%dd %dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+func TestExpiresInUsesFeedClock(t *testing.T) {
+	// The feed's clock is an hour ahead of ours, so quakes age out an hour sooner than our clock says
+	generated := time.Now().Add(time.Hour)
+	q := &quakeTable{maxAge: time.Hour}
+	q.setMetadata(usgs.Metadata{Generated: generated.UnixNano() / int64(time.Millisecond)})
+
+	quake := testQuake("a", 3, generated.Add(-50*time.Minute).UnixNano()/int64(time.Millisecond))
+	left, ok := q.expiresIn(quake)
+	if !ok {
+		t.Fatal("expiresIn says quakes are kept, want them to age out")
+	}
+	if left < 9*time.Minute || left > 10*time.Minute {
+		t.Errorf("expiresIn = %v, want about 10m", left)
+	}
+	if feedFilter(q.maxAge, generated).tooOld(quake) {
+		t.Error("filter says the quake's too old already")
+	}
+	if !feedFilter(q.maxAge, generated.Add(11*time.Minute)).tooOld(quake) {
+		t.Error("filter still keeps the quake after it should have aged out")
+	}
+}
+
+func TestExpiresInKeptForever(t *testing.T) {
+	q := &quakeTable{}
+	if _, ok := q.expiresIn(testQuake("a", 3, 0)); ok {
+		t.Error("expiresIn says quakes age out with no max age")
+	}
+	if text := q.expiryText(testQuake("a", 3, 0)); text != "" {
+		t.Errorf("expiryText = %q, want nothing", text)
+	}
+}
+
+// Get the filter pruning would use for a feed generated at a time
+func feedFilter(maxAge time.Duration, generated time.Time) quakeFilter {
+	return quakeFilter{maxAge: maxAge, generated: generated.UnixNano() / int64(time.Millisecond)}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{42 * time.Second, "42s"},
+		{5 * time.Minute, "5m"},
+		{72 * time.Minute, "1h 12m"},
+		{50 * time.Hour, "2d 2h"},
+	}
+
+	for _, test := range tests {
+		if got := formatCountdown(test.in); got != test.want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
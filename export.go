@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"  // Needed to write CSV exports
+	"encoding/json" // Needed to write JSON exports
+	"os"            // Needed to create the export files
+	"strconv"       // Needed to format numbers for CSV
+	"time"          // Needed to format the quake times
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// A quake as it's written out when exporting
+type exportedQuake struct {
+	ID        string   `json:"id"`
+	Time      string   `json:"time"`
+	Magnitude *float64 `json:"magnitude"`
+	Depth     *float64 `json:"depth"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Place     string   `json:"place"`
+	Alert     string   `json:"alert"`
+	Tsunami   bool     `json:"tsunami"`
+	URL       string   `json:"url"`
+}
+
+// Header row for CSV exports, in the same order as exportedQuake
+var exportHeader = []string{"id", "time", "magnitude", "depth", "latitude", "longitude", "place", "alert", "tsunami", "url"}
+
+// Convert quakes into the form we export them in
+func exportQuakes(rows []quakeRow) []exportedQuake {
+	quakes := make([]exportedQuake, 0, len(rows))
+	for _, row := range rows {
+		quakes = append(quakes, exportQuake(row.quake))
+	}
+
+	return quakes
+}
+
+// Convert a quake into the form we export it in
+func exportQuake(q usgs.Feature) exportedQuake {
+	exported := exportedQuake{
+		ID:        q.ID,
+		Time:      fromMillis(q.Properties.Time).UTC().Format(time.RFC3339),
+		Magnitude: q.Properties.Mag,
+		Place:     q.Properties.Place,
+		Alert:     q.Properties.Alert,
+		Tsunami:   q.Properties.Tsunami == 1,
+		URL:       q.Properties.URL,
+	}
+
+	// GeoJSON coordinates are longitude, latitude, depth
+	coords := q.Geometry.Coordinates
+	if len(coords) >= 2 {
+		exported.Longitude = &coords[0]
+		exported.Latitude = &coords[1]
+	}
+	if len(coords) >= 3 {
+		exported.Depth = &coords[2]
+	}
+
+	return exported
+}
+
+// Get the CSV fields for a quake, in the same order as exportHeader
+func csvRecord(q exportedQuake) []string {
+	optional := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+
+	return []string{
+		q.ID,
+		q.Time,
+		optional(q.Magnitude),
+		optional(q.Depth),
+		optional(q.Latitude),
+		optional(q.Longitude),
+		q.Place,
+		q.Alert,
+		strconv.FormatBool(q.Tsunami),
+		q.URL,
+	}
+}
+
+// Write quakes to a CSV file
+func writeCSV(path string, quakes []exportedQuake) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(exportHeader); err != nil {
+		return err
+	}
+	for _, q := range quakes {
+		if err := w.Write(csvRecord(q)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// Write quakes to a JSON file
+func writeJSON(path string, quakes []exportedQuake) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(quakes); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
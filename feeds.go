@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context" // Needed to cancel fetches on shutdown
+	"sort"    // Needed to put merged quakes back in order
+	"strings" // Needed to split and join feed names
+	"sync"    // Needed to fetch the feeds at the same time
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// The -feed flag, which can be repeated or given a comma separated list
+type feedList []string
+
+func (f *feedList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *feedList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*f = append(*f, name)
+		}
+	}
+
+	return nil
+}
+
+// Needed so -write-config quotes the list
+func (f *feedList) Get() interface{} {
+	return f.String()
+}
+
+// A source and the feed name it fetches, for reporting how each fetch went
+type namedSource struct {
+	name   string
+	source quakeSource
+}
+
+// Get a source that fetches several feeds at once and merges them into one
+// A quake in more than one feed is taken from whichever has the newest update
+// Feeds that fail or haven't changed are merged in from the last time they were fetched,
+// and how each one went is put in the metadata title for the summary bar
+func mergedSource(feeds []namedSource) quakeSource {
+	last := make([]*usgs.Feed, len(feeds))
+
+	return func(ctx context.Context) (usgs.Feed, error) {
+		results := make([]usgs.Feed, len(feeds))
+		errs := make([]error, len(feeds))
+
+		var wg sync.WaitGroup
+		for i, feed := range feeds {
+			wg.Add(1)
+			go func(i int, feed namedSource) {
+				defer wg.Done()
+				results[i], errs[i] = feed.source(ctx)
+			}(i, feed)
+		}
+		wg.Wait()
+
+		if ctx.Err() != nil {
+			return usgs.Feed{}, ctx.Err()
+		}
+
+		var firstErr error
+		changed := false
+		statuses := make([]string, len(feeds))
+		for i, feed := range feeds {
+			switch errs[i] {
+			case nil:
+				last[i] = &results[i]
+				changed = true
+				statuses[i] = feed.name + " ok"
+			case usgs.ErrNotModified:
+				statuses[i] = feed.name + " unchanged"
+			default:
+				if firstErr == nil {
+					firstErr = errs[i]
+				}
+				statuses[i] = feed.name + " failed"
+			}
+		}
+
+		// Only an error if there's nothing new to show for it
+		if !changed {
+			if firstErr != nil {
+				return usgs.Feed{}, firstErr
+			}
+			return usgs.Feed{}, usgs.ErrNotModified
+		}
+
+		return mergeFeeds(last, strings.Join(statuses, " · ")), nil
+	}
+}
+
+// Merge feeds into one, keeping the most recently updated copy of each quake
+// Feeds that have never been fetched are nil and skipped
+func mergeFeeds(feeds []*usgs.Feed, title string) usgs.Feed {
+	merged := usgs.Feed{Type: "FeatureCollection"}
+	merged.Metadata.Title = title
+
+	byID := make(map[string]usgs.Feature)
+	for _, feed := range feeds {
+		if feed == nil {
+			continue
+		}
+		if feed.Metadata.Generated > merged.Metadata.Generated {
+			merged.Metadata.Generated = feed.Metadata.Generated
+		}
+		merged.Warnings = append(merged.Warnings, feed.Warnings...)
+		for _, quake := range feed.Features {
+			if seen, ok := byID[quake.ID]; !ok || quake.Properties.Updated > seen.Properties.Updated {
+				byID[quake.ID] = quake
+			}
+		}
+	}
+
+	for _, quake := range byID {
+		merged.Features = append(merged.Features, quake)
+	}
+
+	// Newest first like the feeds themselves
+	sort.Slice(merged.Features, func(i, j int) bool {
+		a, b := merged.Features[i].Properties, merged.Features[j].Properties
+		if a.Time != b.Time {
+			return a.Time > b.Time
+		}
+		return merged.Features[i].ID < merged.Features[j].ID
+	})
+	merged.Metadata.Count = len(merged.Features)
+
+	return merged
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Make a quake that was last updated at a time, in ms
+func updatedQuake(id string, mag float64, updated int64) usgs.Feature {
+	quake := testQuake(id, mag, 1000)
+	quake.Properties.Updated = updated
+
+	return quake
+}
+
+func TestMergeFeeds(t *testing.T) {
+	hour := &usgs.Feed{Metadata: usgs.Metadata{Generated: 5000}, Features: []usgs.Feature{
+		updatedQuake("shared", 4.4, 3000),
+		updatedQuake("hourOnly", 2, 2000),
+	}}
+	month := &usgs.Feed{Metadata: usgs.Metadata{Generated: 4000}, Features: []usgs.Feature{
+		updatedQuake("shared", 4.6, 4000),
+		testQuake("monthOnly", 6, 500),
+	}}
+
+	tests := []struct {
+		name  string
+		feeds []*usgs.Feed
+		mag   float64 // Of the shared quake
+		count int
+	}{
+		{"newer in the second feed", []*usgs.Feed{hour, month}, 4.6, 3},
+		{"newer in the first feed", []*usgs.Feed{month, hour}, 4.6, 3},
+		{"a feed that's never been fetched", []*usgs.Feed{hour, nil}, 4.4, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := mergeFeeds(test.feeds, "title")
+			if len(merged.Features) != test.count || merged.Metadata.Count != test.count {
+				t.Fatalf("merged %d quakes with a count of %d, want %d", len(merged.Features), merged.Metadata.Count, test.count)
+			}
+			if merged.Metadata.Generated != 5000 {
+				t.Errorf("generated = %d, want the newest, 5000", merged.Metadata.Generated)
+			}
+			for _, quake := range merged.Features {
+				if quake.ID == "shared" {
+					if mag, _ := quakeMagnitude(quake); mag != test.mag {
+						t.Errorf("shared quake has magnitude %v, want %v", mag, test.mag)
+					}
+				}
+			}
+			for i := 1; i < len(merged.Features); i++ {
+				if merged.Features[i].Properties.Time > merged.Features[i-1].Properties.Time {
+					t.Errorf("merged quakes aren't newest first: %v", rowIDs(rowsOf(merged.Features)))
+				}
+			}
+		})
+	}
+}
+
+// Wrap quakes in rows
+func rowsOf(quakes []usgs.Feature) []quakeRow {
+	var rows []quakeRow
+	for _, quake := range quakes {
+		rows = append(rows, quakeRow{quake: quake})
+	}
+
+	return rows
+}
+
+// A source that gives back each of its results in turn
+func scriptedSource(results ...interface{}) quakeSource {
+	return func(ctx context.Context) (usgs.Feed, error) {
+		result := results[0]
+		if len(results) > 1 {
+			results = results[1:]
+		}
+		if err, ok := result.(error); ok {
+			return usgs.Feed{}, err
+		}
+		return result.(usgs.Feed), nil
+	}
+}
+
+func TestMergedSource(t *testing.T) {
+	hourFeed := usgs.Feed{Features: []usgs.Feature{updatedQuake("shared", 4.4, 3000)}}
+	monthFeed := usgs.Feed{Features: []usgs.Feature{updatedQuake("shared", 4.6, 4000), testQuake("big", 6, 500)}}
+	failed := errors.New("connection refused")
+
+	source := mergedSource([]namedSource{
+		{"all_hour", scriptedSource(hourFeed, usgs.ErrNotModified, failed)},
+		{"significant_month", scriptedSource(monthFeed, usgs.ErrNotModified, usgs.ErrNotModified)},
+	})
+
+	steps := []struct {
+		title   string
+		count   int
+		wantErr error
+	}{
+		{"all_hour ok · significant_month ok", 2, nil},
+		{"", 0, usgs.ErrNotModified},
+		{"", 0, failed},
+	}
+
+	for i, step := range steps {
+		feed, err := source(context.Background())
+		if err != step.wantErr {
+			t.Errorf("fetch %d: err = %v, want %v", i+1, err, step.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if feed.Metadata.Title != step.title || len(feed.Features) != step.count {
+			t.Errorf("fetch %d: %q with %d quakes, want %q with %d", i+1, feed.Metadata.Title, len(feed.Features), step.title, step.count)
+		}
+	}
+}
+
+func TestMergedSourceKeepsFeedsThatFailed(t *testing.T) {
+	failed := errors.New("connection refused")
+	source := mergedSource([]namedSource{
+		{"all_hour", scriptedSource(usgs.Feed{Features: []usgs.Feature{testQuake("a", 2, 1000)}}, usgs.Feed{Features: []usgs.Feature{testQuake("b", 2, 2000)}})},
+		{"significant_month", scriptedSource(usgs.Feed{Features: []usgs.Feature{testQuake("big", 6, 500)}}, failed)},
+	})
+
+	source(context.Background())
+	feed, err := source(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rowIDs(rowsOf(feed.Features)), []string{"b", "big"}; !equalStrings(got, want) {
+		t.Errorf("merged %v, want %v", got, want)
+	}
+	if want := "all_hour ok · significant_month failed"; feed.Metadata.Title != want {
+		t.Errorf("title = %q, want %q", feed.Metadata.Title, want)
+	}
+}
+
+func TestFeedList(t *testing.T) {
+	var feeds feedList
+	feeds.Set("all_hour, significant_month")
+	feeds.Set("4.5_day")
+	if got, want := []string(feeds), []string{"all_hour", "significant_month", "4.5_day"}; !equalStrings(got, want) {
+		t.Errorf("feeds = %v, want %v", got, want)
+	}
+}
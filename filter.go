@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"regexp"  // Needed for regex place filters
+	"strings" // Needed for substring place filters
+	"time"    // Needed to work out how old quakes are
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Filters applied to quakes before they're added to the table
+type quakeFilter struct {
+	minMagnitude float64
+	minSig       int
+	maxAge       time.Duration // 0 keeps quakes forever
+	generated    int64         // When the feed was generated in ms, ages are measured from here if it's set
+	showDeleted  bool
+	onlyTsunami  bool
+	types        typeList // Empty shows every type
+	excludeTypes typeList
+	status       string // reviewed or automatic, anything else shows both
+	place        func(string) bool
+	near         *geoRadius // Only set when filtering locally, FDSN queries filter by radius server side
+	box          *geoBox    // Likewise for the bounding box
+	watch        watchList  // Quakes in these regions are shown whatever the other filters say
+}
+
+// Check if a quake should be shown, quakes in a watch region only have to be recent enough
+func (f quakeFilter) shows(quake usgs.Feature) bool {
+	if f.watch.match(quake) != "" {
+		return !f.tooOld(quake)
+	}
+
+	return f.matches(quake)
+}
+
+// Check if a quake passes the filters
+// A minimum magnitude of 0 or less is off, so microquakes with negative magnitudes still show by default
+// Quakes without a magnitude are treated as below any positive threshold
+func (f quakeFilter) matches(quake usgs.Feature) bool {
+	if quake.Properties.Sig < f.minSig {
+		return false
+	}
+	if f.onlyTsunami && quake.Properties.Tsunami != 1 {
+		return false
+	}
+	if !f.wantsStatus(quake.Properties.Status) {
+		return false
+	}
+	if !f.wantsType(quake.Properties.Type) {
+		return false
+	}
+	if f.place != nil && !f.place(quake.Properties.Place) {
+		return false
+	}
+	if f.outsideArea(quake) {
+		return false
+	}
+
+	if mag, _ := quakeMagnitude(quake); f.minMagnitude > 0 && mag < f.minMagnitude {
+		return false
+	}
+
+	return !f.tooOld(quake)
+}
+
+// Check if a review status gets past the status filter
+// Quakes without a status pass, since not every source reports one
+func (f quakeFilter) wantsStatus(status string) bool {
+	switch f.status {
+	case "reviewed", "automatic":
+		return status == "" || status == f.status
+	}
+
+	return true
+}
+
+// Check if an event type, eg: "quarry blast", gets past the type filters
+// Quakes without a type pass, since not every source reports one
+func (f quakeFilter) wantsType(eventType string) bool {
+	if eventType == "" {
+		return true
+	}
+	if f.excludeTypes.contains(eventType) {
+		return false
+	}
+
+	return len(f.types) == 0 || f.types.contains(eventType)
+}
+
+// Check if a quake is outside the radius or box we're filtering by
+func (f quakeFilter) outsideArea(quake usgs.Feature) bool {
+	return (f.near != nil && !f.near.contains(quake)) || (f.box != nil && !f.box.contains(quake))
+}
+
+// Check if a quake is older than we keep quakes for
+// Ages are measured from when the feed was generated if we know, so a clock that's ahead of
+// USGS's doesn't hide brand new quakes
+func (f quakeFilter) tooOld(quake usgs.Feature) bool {
+	now := time.Now()
+	if f.generated != 0 {
+		now = fromMillis(f.generated)
+	}
+
+	return f.maxAge > 0 && now.Sub(fromMillis(quake.Properties.Time)) > f.maxAge
+}
+
+// The -event-type and -exclude-type flags, which can be repeated or given a comma separated list
+type typeList []string
+
+func (t *typeList) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *typeList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*t = append(*t, name)
+		}
+	}
+
+	return nil
+}
+
+// Needed so -write-config quotes the list
+func (t *typeList) Get() interface{} {
+	return t.String()
+}
+
+// Check if an event type is in the list, ignoring case
+func (t typeList) contains(eventType string) bool {
+	for _, name := range t {
+		if strings.EqualFold(name, eventType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Build a matcher for quake places from a case-insensitive substring or regular expression
+// An empty pattern gives a nil matcher, which means everything matches
+func newPlaceMatcher(pattern string, isRegex bool) (func(string) bool, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	if isRegex {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid place regex %q: %v", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	pattern = strings.ToLower(pattern)
+	return func(place string) bool {
+		return strings.Contains(strings.ToLower(place), pattern)
+	}, nil
+}
+
+// Remove quakes that have gotten too old from the quake list
+// Returns the quakes removed so they can be taken out of the table too
+func pruneQuakes(quakeList map[string]usgs.Feature, filter quakeFilter) []usgs.Feature {
+	var removed []usgs.Feature
+	for id, quake := range quakeList {
+		if filter.tooOld(quake) {
+			delete(quakeList, id)
+			removed = append(removed, quake)
+		}
+	}
+
+	return removed
+}
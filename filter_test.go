@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+func TestPruneHalfAgeOut(t *testing.T) {
+	generated := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+	filter := quakeFilter{maxAge: time.Hour}
+
+	// Ten quakes from 5 to 50 minutes old, the oldest five are over an hour old 31 minutes later
+	var quakes []usgs.Feature
+	for i := 0; i < 10; i++ {
+		quakes = append(quakes, testQuake(fmt.Sprintf("q%d", i), 3, millis(generated.Add(-time.Duration(5+5*i)*time.Minute))))
+	}
+	quakeList := make(map[string]usgs.Feature)
+	first := usgs.Feed{Metadata: usgs.Metadata{Generated: millis(generated)}, Features: quakes}
+	if changes, _ := diffQuakes(first, quakeList, filter, quakeAlerts{}); len(changes) != 10 {
+		t.Fatalf("first fetch has %d changes, want 10 new quakes", len(changes))
+	}
+
+	// Like the hour feed, the next one has dropped them, and none of the others have changed
+	second := usgs.Feed{Metadata: usgs.Metadata{Generated: millis(generated.Add(31 * time.Minute))}, Features: quakes[:5]}
+	changes, _ := diffQuakes(second, quakeList, filter, quakeAlerts{})
+	var removed []string
+	for _, change := range changes {
+		if change.action != changeRemove {
+			t.Errorf("%s was %s, want only removals", change.quake.ID, changeActions[change.action])
+			continue
+		}
+		removed = append(removed, change.quake.ID)
+	}
+	sort.Strings(removed)
+	if want := []string{"q5", "q6", "q7", "q8", "q9"}; !equalStrings(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+	if len(quakeList) != 5 {
+		t.Errorf("quake list has %d quakes, want 5", len(quakeList))
+	}
+}
+
+func TestPruneKeepAll(t *testing.T) {
+	quakeList := map[string]usgs.Feature{"old": testQuake("old", 3, 0)}
+	if removed := pruneQuakes(quakeList, quakeFilter{generated: millis(time.Now())}); len(removed) != 0 || len(quakeList) != 1 {
+		t.Errorf("removed %d quakes without a max age, want none", len(removed))
+	}
+}
+
+func TestTooOld(t *testing.T) {
+	generated := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		age  time.Duration
+		want bool
+	}{
+		{59 * time.Minute, false},
+		{time.Hour, false},
+		{61 * time.Minute, true},
+		{-time.Minute, false}, // Our clock is behind the quake's
+	}
+
+	filter := quakeFilter{maxAge: time.Hour, generated: millis(generated)}
+	for _, test := range tests {
+		quake := testQuake("a", 3, millis(generated.Add(-test.age)))
+		if got := filter.tooOld(quake); got != test.want {
+			t.Errorf("%v old: tooOld = %v, want %v", test.age, got, test.want)
+		}
+	}
+}
+
+func TestMinMagnitudeFilter(t *testing.T) {
+	unknown := testQuake("unknown", 0, 1000)
+	unknown.Properties.Mag = nil
+
+	tests := []struct {
+		name   string
+		minMag float64
+		quake  usgs.Feature
+		want   bool
+	}{
+		{"microquake by default", 0, testQuake("micro", -0.8, 1000), true},
+		{"unknown by default", 0, unknown, true},
+		{"above", 2.5, testQuake("a", 3, 1000), true},
+		{"at", 2.5, testQuake("a", 2.5, 1000), true},
+		{"below", 2.5, testQuake("a", 2.4, 1000), false},
+		{"microquake under a threshold", 2.5, testQuake("micro", -0.8, 1000), false},
+		{"unknown under a threshold", 2.5, unknown, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := (quakeFilter{minMagnitude: test.minMag}).shows(test.quake); got != test.want {
+				t.Errorf("shows = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEventTypeFilter(t *testing.T) {
+	feed, err := readFeedFile("testdata/mixed_types.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nc73524700 has no type, so it gets through every type filter
+	tests := []struct {
+		name    string
+		types   typeList
+		exclude typeList
+		want    []string
+	}{
+		{"no filter", nil, nil, []string{"nc73524700", "ak0212ynsq5a", "uu60432942", "nn00800771", "nc73524811"}},
+		{"earthquakes only", typeList{"earthquake"}, nil, []string{"nc73524700", "nc73524811"}},
+		{"not blasts", nil, typeList{"quarry blast", "Explosion"}, []string{"nc73524700", "ak0212ynsq5a", "nc73524811"}},
+		{"earthquakes and ice quakes", typeList{"earthquake", "ice quake"}, nil, []string{"nc73524700", "ak0212ynsq5a", "nc73524811"}},
+		{"excluding wins", typeList{"earthquake", "explosion"}, typeList{"explosion"}, []string{"nc73524700", "nc73524811"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := quakeFilter{types: test.types, excludeTypes: test.exclude}
+			changes, _ := diffQuakes(feed, make(map[string]usgs.Feature), filter, quakeAlerts{})
+			if got := changedIDs(changes); !equalStrings(got, test.want) {
+				t.Errorf("shown %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTypeListSet(t *testing.T) {
+	var types typeList
+	types.Set("quarry blast, explosion")
+	types.Set("ice quake")
+	if got, want := []string(types), []string{"quarry blast", "explosion", "ice quake"}; !equalStrings(got, want) {
+		t.Errorf("types = %v, want %v", got, want)
+	}
+	if !types.contains("Quarry Blast") {
+		t.Error("contains doesn't ignore case")
+	}
+}
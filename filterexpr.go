@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"strconv" // Needed to parse the numbers
+	"strings" // Needed to split the expression
+	"unicode" // Needed to find the end of the field name
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// A field the '/' filter can match on, either a number or some text
+type filterField struct {
+	number func(usgs.Feature) (float64, bool) // The bool is false if the quake doesn't have it
+	text   func(usgs.Feature) string
+}
+
+// Fields the '/' filter knows, eg: mag>4 or place:japan
+var filterFields = map[string]filterField{
+	"mag":   {number: quakeMagnitude},
+	"depth": {number: quakeDepth},
+	"sig":   {number: func(y usgs.Feature) (float64, bool) { return float64(y.Properties.Sig), true }},
+	"felt":  {number: func(y usgs.Feature) (float64, bool) { return float64(y.Properties.Felt), true }},
+	"place": {text: quakePlace},
+	"alert": {text: func(y usgs.Feature) string { return y.Properties.Alert }},
+}
+
+// Operators the '/' filter understands, two character ones first so >= isn't read as >
+var filterOperators = []string{">=", "<=", "!=", ">", "<", "=", ":"}
+
+// Parse a filter like "mag>4 place:japan depth<70" into a matcher that needs every term to match
+// Words without an operator match the place, so the filter works the same as it always has for
+// places, and with placeRegex the place values are regular expressions
+// Quakes missing a number, like a magnitude, never match a term on it
+// An empty filter gives a nil matcher, which means everything matches
+func parseFilterExpr(expr string, placeRegex bool) (func(usgs.Feature) bool, error) {
+	terms, err := splitFilterExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var matchers []func(usgs.Feature) bool
+	for _, term := range terms {
+		match, err := parseFilterTerm(term, placeRegex)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, match)
+	}
+
+	return func(quake usgs.Feature) bool {
+		for _, match := range matchers {
+			if !match(quake) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// Split a filter into its terms on spaces, keeping anything in double quotes together,
+// eg: place:"new zealand" mag>5
+func splitFilterExpr(expr string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid filter: missing closing quote")
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+
+	return terms, nil
+}
+
+// Parse a single term of a filter, eg: mag>=4.5
+func parseFilterTerm(term string, placeRegex bool) (func(usgs.Feature) bool, error) {
+	// The field is the letters up to the operator
+	end := strings.IndexFunc(term, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		end = len(term)
+	}
+	name, rest := strings.ToLower(term[:end]), term[end:]
+
+	op := ""
+	for _, candidate := range filterOperators {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	// Just a word, so it's a place
+	if op == "" || end == 0 {
+		return textFilter(quakePlace, ":", term, placeRegex)
+	}
+
+	field, ok := filterFields[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid filter %q: unknown field %q, try mag, depth, place, alert, sig, or felt", term, name)
+	}
+	value := rest[len(op):]
+	if value == "" {
+		return nil, fmt.Errorf("invalid filter %q: missing a value after %s", term, op)
+	}
+
+	if field.text != nil {
+		return textFilter(field.text, op, value, placeRegex && name == "place")
+	}
+
+	return numberFilter(field.number, op, value)
+}
+
+// Build a matcher comparing a text field, : looks for the value in the text, = and != compare the
+// whole text, and neither cares about case
+func textFilter(text func(usgs.Feature) string, op, value string, isRegex bool) (func(usgs.Feature) bool, error) {
+	switch op {
+	case ":":
+		match, err := newPlaceMatcher(value, isRegex)
+		if err != nil {
+			return nil, err
+		}
+		return func(quake usgs.Feature) bool { return match(text(quake)) }, nil
+	case "=":
+		return func(quake usgs.Feature) bool { return strings.EqualFold(text(quake), value) }, nil
+	case "!=":
+		return func(quake usgs.Feature) bool { return !strings.EqualFold(text(quake), value) }, nil
+	}
+
+	return nil, fmt.Errorf("invalid filter: %s only works on numbers, use : to search text", op)
+}
+
+// Build a matcher comparing a number field, : is the same as =
+func numberFilter(number func(usgs.Feature) (float64, bool), op, value string) (func(usgs.Feature) bool, error) {
+	want, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %q is not a number", value)
+	}
+
+	compare := map[string]func(float64) bool{
+		">=": func(have float64) bool { return have >= want },
+		"<=": func(have float64) bool { return have <= want },
+		"!=": func(have float64) bool { return have != want },
+		">":  func(have float64) bool { return have > want },
+		"<":  func(have float64) bool { return have < want },
+		"=":  func(have float64) bool { return have == want },
+		":":  func(have float64) bool { return have == want },
+	}[op]
+
+	return func(quake usgs.Feature) bool {
+		have, ok := number(quake)
+		return ok && compare(have)
+	}, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Quakes to run filters against
+func filterExprQuakes() []usgs.Feature {
+	tokyo := testQuake("tokyo", 4.5, 3000)
+	tokyo.Properties.Place = "10 km E of Tokyo, Japan"
+	tokyo.Properties.Alert = "green"
+	tokyo.Properties.Sig = 312
+	tokyo.Properties.Felt = 12
+
+	wellington := testQuake("wellington", 2.1, 2000)
+	wellington.Properties.Place = "5 km N of Wellington, New Zealand"
+	wellington.Geometry.Coordinates[2] = 80
+
+	alaska := testQuake("alaska", 0, 1000)
+	alaska.Properties.Mag = nil
+	alaska.Properties.Place = "Southern Alaska"
+	alaska.Geometry.Coordinates = []float64{-150, 61} // No depth either
+
+	return []usgs.Feature{tokyo, wellington, alaska}
+}
+
+func TestParseFilterExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		regex bool
+		want  []string // IDs that match
+	}{
+		{"", false, []string{"tokyo", "wellington", "alaska"}},
+		{"   ", false, []string{"tokyo", "wellington", "alaska"}},
+		{"mag>4", false, []string{"tokyo"}},
+		{"mag>=2.1", false, []string{"tokyo", "wellington"}},
+		{"mag<4.5", false, []string{"wellington"}}, // Alaska has no magnitude
+		{"mag!=4.5", false, []string{"wellington"}},
+		{"mag=4.5", false, []string{"tokyo"}},
+		{"mag:4.5", false, []string{"tokyo"}},
+		{"MAG>4", false, []string{"tokyo"}},
+		{"depth<70", false, []string{"tokyo"}},
+		{"depth>=80", false, []string{"wellington"}},
+		{"sig>300", false, []string{"tokyo"}},
+		{"felt=0", false, []string{"wellington", "alaska"}},
+		{"place:japan", false, []string{"tokyo"}},
+		{"japan", false, []string{"tokyo"}},
+		{`place:"new zealand"`, false, []string{"wellington"}},
+		{`"new zealand"`, false, []string{"wellington"}},
+		{"place=southern alaska", false, nil}, // The space splits it into two terms
+		{`place="southern alaska"`, false, []string{"alaska"}},
+		{"alert=green", false, []string{"tokyo"}},
+		{"alert!=green", false, []string{"wellington", "alaska"}},
+		{"mag>2 depth<70 place:japan", false, []string{"tokyo"}},
+		{"mag>2 place:alaska", false, nil},
+		{`place:"^(southern|10 km)"`, true, []string{"tokyo", "alaska"}},
+		{`place:"^(southern|10 km)"`, false, nil},
+		{"alert:gr.en", true, nil}, // Only places are regular expressions
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			match, err := parseFilterExpr(test.expr, test.regex)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, quake := range filterExprQuakes() {
+				if match == nil || match(quake) {
+					got = append(got, quake.ID)
+				}
+			}
+			if !equalStrings(got, test.want) {
+				t.Errorf("matched %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterExprErrors(t *testing.T) {
+	tests := []struct {
+		expr  string
+		regex bool
+		want  string // In the error
+	}{
+		{"size>4", false, `unknown field "size"`},
+		{"mag>", false, "missing a value after >"},
+		{"mag>big", false, `"big" is not a number`},
+		{"place>japan", false, "only works on numbers"},
+		{`place:"new zealand`, false, "missing closing quote"},
+		{"place:(", true, "invalid place regex"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := parseFilterExpr(test.expr, test.regex)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error = %v, want it to mention %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"math"    // Needed for the great-circle maths
+	"strconv" // Needed to parse coordinates
+	"strings" // Needed to split the radius
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Mean radius of the Earth in km
+const EARTHRADIUS = 6371.0
+
+// Kilometres in a mile
+const KMPERMILE = 1.609344
+
+// A latitude and longitude in degrees
+type geoPoint struct {
+	lat float64
+	lon float64
+}
+
+// A circle around a point, radius is in km
+type geoRadius struct {
+	center geoPoint
+	radius float64
+}
+
+// A latitude and longitude box, a minLon above maxLon means it crosses the antimeridian
+type geoBox struct {
+	minLat float64
+	minLon float64
+	maxLat float64
+	maxLon float64
+}
+
+// Get where a quake's epicenter is, if it has coordinates
+// GeoJSON coordinates are longitude, latitude, depth
+func quakePoint(quake usgs.Feature) (geoPoint, bool) {
+	if len(quake.Geometry.Coordinates) < 2 {
+		return geoPoint{}, false
+	}
+
+	return geoPoint{lat: quake.Geometry.Coordinates[1], lon: quake.Geometry.Coordinates[0]}, true
+}
+
+// Get the great-circle distance in km between two points using the haversine formula
+func haversine(a, b geoPoint) float64 {
+	lat1 := a.lat * math.Pi / 180
+	lat2 := b.lat * math.Pi / 180
+	dLat := (b.lat - a.lat) * math.Pi / 180
+	dLon := (b.lon - a.lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	// Rounding can push h just past 1 for antipodal points
+	return 2 * EARTHRADIUS * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+// The 16 compass points, clockwise from north
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// Get the initial great-circle bearing from a to b in degrees, 0 is north and 90 is east
+func bearing(a, b geoPoint) float64 {
+	lat1 := a.lat * math.Pi / 180
+	lat2 := b.lat * math.Pi / 180
+	dLon := (b.lon - a.lon) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// Get the nearest of the 16 compass points to a bearing, eg: 312 is NW
+func compassPoint(degrees float64) string {
+	// Each point covers 22.5 degrees centred on it, so N runs from 348.75 to 11.25
+	point := int(math.Floor(math.Mod(degrees, 360)/22.5+0.5)) % len(compassPoints)
+	if point < 0 {
+		point += len(compassPoints)
+	}
+
+	return compassPoints[point]
+}
+
+// Format a point in decimal degrees to 4 places, about 10 m, eg: 38.8339, -122.8070
+func formatDecimal(point geoPoint) string {
+	return fmt.Sprintf("%.4f, %.4f", point.lat, point.lon)
+}
+
+// Format a point in degrees, minutes and seconds, eg: 38°50′02″N 122°48′25″W
+func formatDMS(point geoPoint) string {
+	return dms(point.lat, "N", "S") + " " + dms(point.lon, "E", "W")
+}
+
+// Convert a latitude or longitude to degrees, minutes and seconds with its hemisphere
+// It's rounded to the nearest second first, so 59.6″ carries into the minutes instead of showing as 60″
+func dms(degrees float64, positive, negative string) string {
+	hemisphere := positive
+	if degrees < 0 {
+		hemisphere = negative
+		degrees = -degrees
+	}
+
+	seconds := int(math.Round(degrees * 3600))
+	return fmt.Sprintf("%d°%02d′%02d″%s", seconds/3600, seconds/60%60, seconds%60, hemisphere)
+}
+
+// Parse a latitude and longitude, making sure they're in range
+func parsePoint(lat, lon string) (geoPoint, error) {
+	var point geoPoint
+	var err error
+
+	if point.lat, err = strconv.ParseFloat(lat, 64); err != nil || point.lat < -90 || point.lat > 90 {
+		return point, fmt.Errorf("invalid latitude %q: must be a number between -90 and 90", lat)
+	}
+	if point.lon, err = strconv.ParseFloat(lon, 64); err != nil || point.lon < -180 || point.lon > 180 {
+		return point, fmt.Errorf("invalid longitude %q: must be a number between -180 and 180", lon)
+	}
+
+	return point, nil
+}
+
+// Parse a radius in the form lat,lon,km
+func parseRadius(value string) (geoRadius, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return geoRadius{}, fmt.Errorf("invalid radius %q: expected lat,lon,km", value)
+	}
+
+	center, err := parsePoint(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	if err != nil {
+		return geoRadius{}, fmt.Errorf("invalid radius %q: %v", value, err)
+	}
+
+	radius, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+	if err != nil || radius <= 0 {
+		return geoRadius{}, fmt.Errorf("invalid radius %q: the distance must be a number of km above 0", value)
+	}
+
+	return geoRadius{center: center, radius: radius}, nil
+}
+
+// Check if a quake is within the radius, quakes without coordinates never are
+func (r geoRadius) contains(quake usgs.Feature) bool {
+	point, ok := quakePoint(quake)
+
+	return ok && haversine(r.center, point) <= r.radius
+}
+
+// Check if a box crosses the antimeridian
+func (b geoBox) wraps() bool {
+	return b.minLon > b.maxLon
+}
+
+// Check if a quake is inside the box, quakes without coordinates never are
+func (b geoBox) contains(quake usgs.Feature) bool {
+	point, ok := quakePoint(quake)
+	if !ok || point.lat < b.minLat || point.lat > b.maxLat {
+		return false
+	}
+
+	if b.wraps() {
+		return point.lon >= b.minLon || point.lon <= b.maxLon
+	}
+
+	return point.lon >= b.minLon && point.lon <= b.maxLon
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Make a quake at a point for tests
+func quakeAt(lat, lon float64) usgs.Feature {
+	return usgs.Feature{ID: "test", Geometry: usgs.Geometry{Coordinates: []float64{lon, lat, 10}}}
+}
+
+func TestGeoBoxContains(t *testing.T) {
+	aleutians := geoBox{minLat: 50, minLon: 170, maxLat: 60, maxLon: -170}
+	arctic := geoBox{minLat: 80, minLon: -180, maxLat: 90, maxLon: 180}
+	antarctic := geoBox{minLat: -90, minLon: -180, maxLat: -60, maxLon: 180}
+	california := geoBox{minLat: 32, minLon: -125, maxLat: 42, maxLon: -114}
+
+	tests := []struct {
+		name  string
+		box   geoBox
+		quake usgs.Feature
+		want  bool
+	}{
+		{"inside", california, quakeAt(38.8, -122.8), true},
+		{"on the edge", california, quakeAt(42, -114), true},
+		{"east of it", california, quakeAt(38.8, -113), false},
+		{"north of it", california, quakeAt(42.1, -122.8), false},
+		{"west of the antimeridian", aleutians, quakeAt(52, 175), true},
+		{"east of the antimeridian", aleutians, quakeAt(52, -175), true},
+		{"on the antimeridian", aleutians, quakeAt(52, 180), true},
+		{"on the antimeridian the other way", aleutians, quakeAt(52, -180), true},
+		{"between the ends of a wrapping box", aleutians, quakeAt(52, 0), false},
+		{"right of a wrapping box", aleutians, quakeAt(52, -169), false},
+		{"left of a wrapping box", aleutians, quakeAt(52, 169), false},
+		{"wrapping box wrong latitude", aleutians, quakeAt(40, 175), false},
+		{"north pole", arctic, quakeAt(90, 45), true},
+		{"near the north pole", arctic, quakeAt(89.99, -179.99), true},
+		{"south of the arctic box", arctic, quakeAt(79.9, 0), false},
+		{"south pole", antarctic, quakeAt(-90, 0), true},
+		{"near the south pole", antarctic, quakeAt(-89.5, 179.5), true},
+		{"no coordinates", california, usgs.Feature{ID: "none"}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.box.contains(test.quake); got != test.want {
+			t.Errorf("%s: contains = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	london := geoPoint{lat: 51.5007, lon: -0.1246}
+	newYork := geoPoint{lat: 40.6892, lon: -74.0445}
+	tests := []struct {
+		name string
+		a, b geoPoint
+		want float64 // km
+	}{
+		{"same point", london, london, 0},
+		{"London to New York", london, newYork, 5575},
+		{"a degree along the equator", geoPoint{0, 0}, geoPoint{0, 1}, 111.2},
+		{"across the antimeridian", geoPoint{0, 179.5}, geoPoint{0, -179.5}, 111.2},
+		{"pole to pole", geoPoint{90, 0}, geoPoint{-90, 0}, math.Pi * EARTHRADIUS},
+		{"antipodes", geoPoint{0, 0}, geoPoint{0, 180}, math.Pi * EARTHRADIUS},
+	}
+
+	for _, test := range tests {
+		got := haversine(test.a, test.b)
+		if math.Abs(got-test.want) > test.want/1000+0.1 {
+			t.Errorf("%s: haversine = %.1f km, want %.1f km", test.name, got, test.want)
+		}
+		if back := haversine(test.b, test.a); math.Abs(back-got) > 1e-9 {
+			t.Errorf("%s: haversine the other way = %.1f km, want %.1f km", test.name, back, got)
+		}
+	}
+}
+
+func TestDistanceText(t *testing.T) {
+	home := geoPoint{lat: 38.8, lon: -122.8}
+	tests := []struct {
+		name  string
+		miles bool
+		quake usgs.Feature
+		want  string
+	}{
+		{"no coordinates", false, usgs.Feature{}, "-"},
+		{"right at home", false, quakeAt(38.8, -122.8), "0 km"},
+		{"km", false, quakeAt(39.8, -122.8), "111 km N"},
+		{"miles", true, quakeAt(39.8, -122.8), "69 mi N"},
+	}
+
+	for _, test := range tests {
+		q := &quakeTable{home: &home, miles: test.miles}
+		if got := q.distanceText(quakeRow{quake: test.quake}); got != test.want {
+			t.Errorf("%s: distanceText = %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	// Without a home there's nothing to measure from
+	q := &quakeTable{}
+	if got := q.distanceText(quakeRow{quake: quakeAt(39.8, -122.8)}); got != "-" {
+		t.Errorf("without a home: distanceText = %q, want \"-\"", got)
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		name string
+		from geoPoint
+		to   geoPoint
+		want float64
+	}{
+		{"north", geoPoint{0, 0}, geoPoint{10, 0}, 0},
+		{"east", geoPoint{0, 0}, geoPoint{0, 10}, 90},
+		{"south", geoPoint{0, 0}, geoPoint{-10, 0}, 180},
+		{"west", geoPoint{0, 0}, geoPoint{0, -10}, 270},
+		{"east across the antimeridian", geoPoint{0, 179}, geoPoint{0, -179}, 90},
+		{"west across the antimeridian", geoPoint{0, -179}, geoPoint{0, 179}, 270},
+		{"london to new york", geoPoint{51.5074, -0.1278}, geoPoint{40.7128, -74.006}, 288.3}, // Not 256, great circles head north first
+	}
+
+	for _, test := range tests {
+		if got := bearing(test.from, test.to); math.Abs(got-test.want) > 0.5 {
+			t.Errorf("%s: bearing = %.1f, want %.1f", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCompassPoint(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		want    string
+	}{
+		{0, "N"},
+		{11.24, "N"},
+		{11.25, "NNE"},
+		{45, "NE"},
+		{90, "E"},
+		{180, "S"},
+		{202.5, "SSW"},
+		{312, "NW"},
+		{348.74, "NNW"},
+		{348.75, "N"},
+		{359.9, "N"},
+		{360, "N"},
+		{-10, "N"},
+		{-90, "W"},
+	}
+
+	for _, test := range tests {
+		if got := compassPoint(test.degrees); got != test.want {
+			t.Errorf("compassPoint(%v) = %q, want %q", test.degrees, got, test.want)
+		}
+	}
+}
+
+func TestNearHome(t *testing.T) {
+	home := geoPoint{lat: 38.8, lon: -122.8}
+	tests := []struct {
+		name      string
+		highlight float64
+		quake     usgs.Feature
+		want      bool
+	}{
+		{"inside", 500, quakeAt(39.8, -122.8), true},
+		{"outside", 100, quakeAt(39.8, -122.8), false},
+		{"no coordinates", 500, usgs.Feature{}, false},
+		{"turned off", 0, quakeAt(38.8, -122.8), false},
+	}
+
+	for _, test := range tests {
+		q := &quakeTable{home: &home, nearHighlight: test.highlight}
+		if got := q.nearHome(quakeRow{quake: test.quake}); got != test.want {
+			t.Errorf("%s: nearHome = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDMS(t *testing.T) {
+	tests := []struct {
+		degrees  float64
+		positive string
+		negative string
+		want     string
+	}{
+		{38.8339, "N", "S", "38°50′02″N"},
+		{-122.807, "E", "W", "122°48′25″W"},
+		{-33.8688, "N", "S", "33°52′08″S"},
+		{151.2093, "E", "W", "151°12′33″E"},
+		{0, "N", "S", "0°00′00″N"},
+		{10.99999, "N", "S", "11°00′00″N"},    // 59.96″ rounds up into the next degree
+		{10.49999, "E", "W", "10°30′00″E"},    // And into the next minute
+		{-179.99999, "E", "W", "180°00′00″W"}, // Even at the antimeridian
+		{90, "N", "S", "90°00′00″N"},
+	}
+
+	for _, test := range tests {
+		if got := dms(test.degrees, test.positive, test.negative); got != test.want {
+			t.Errorf("dms(%v) = %q, want %q", test.degrees, got, test.want)
+		}
+	}
+}
+
+func TestCoordinatesText(t *testing.T) {
+	tests := []struct {
+		name  string
+		dms   bool
+		quake usgs.Feature
+		want  string
+	}{
+		{"decimal", false, quakeAt(38.83391, -122.80702), "38.8339, -122.8070"},
+		{"dms", true, quakeAt(38.8339, -122.807), "38°50′02″N 122°48′25″W"},
+		{"no coordinates", true, usgs.Feature{}, "-"},
+	}
+
+	for _, test := range tests {
+		q := &quakeTable{dms: test.dms}
+		if got := q.coordinatesText(quakeRow{quake: test.quake}); got != test.want {
+			t.Errorf("%s: coordinatesText = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDetailsCoordinates(t *testing.T) {
+	quake := quakeAt(38.8339, -122.807)
+	details := formatDetails(quake, time.UTC)
+	for _, want := range []string{"38.8339, -122.8070", "38°50′02″N 122°48′25″W", "10.0 km"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("details don't have %q:\n%s", want, details)
+		}
+	}
+
+	// Without coordinates there's nothing to show but that the depth isn't known
+	details = formatDetails(usgs.Feature{ID: "test"}, time.UTC)
+	if strings.Contains(details, "DMS") || strings.Contains(details, " km") {
+		t.Errorf("details show coordinates for a quake without any:\n%s", details)
+	}
+}
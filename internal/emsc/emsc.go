@@ -0,0 +1,145 @@
+// Package emsc reads earthquakes from the EMSC FDSN event service into the same form as the USGS feeds
+package emsc
+
+import (
+	"encoding/json" // Needed to parse EMSC data
+	"fmt"           // Needed for errors and titles
+	"io"            // Needed to read the response
+	"time"          // Needed to parse the timestamps
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+const (
+	// The EMSC FDSN event service, see: https://www.seismicportal.eu/fdsn-wsevent.html
+	API = "https://www.seismicportal.eu/fdsnws/event/1/query"
+
+	// Where an event's page is on the EMSC website
+	EVENTPAGE = "https://www.seismicportal.eu/eventdetails.html?unid="
+
+	// What features from the EMSC are tagged with
+	SOURCE = "emsc"
+)
+
+// The parts of the EMSC GeoJSON we use, it's GeoJSON but with its own properties
+type feed struct {
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	ID         string     `json:"id"`
+	Geometry   geometry   `json:"geometry"`
+	Properties properties `json:"properties"`
+}
+
+type geometry struct {
+	Coordinates []float64 `json:"coordinates"` // Not used, Parse takes the position from the lat, lon, and depth properties
+}
+
+type properties struct {
+	Time       string   `json:"time"`
+	LastUpdate string   `json:"lastupdate"`
+	Region     string   `json:"flynn_region"`
+	Lat        float64  `json:"lat"`
+	Lon        float64  `json:"lon"`
+	Depth      *float64 `json:"depth"` // In km, positive down like the USGS
+	EventType  string   `json:"evtype"`
+	Auth       string   `json:"auth"`
+	Mag        *float64 `json:"mag"`
+	MagType    string   `json:"magtype"`
+	Unid       string   `json:"unid"`
+}
+
+// Parse an EMSC response into the same form as the USGS feeds
+func Parse(r io.Reader) (usgs.Feed, error) {
+	result := usgs.Feed{Type: "FeatureCollection"}
+
+	var data feed
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return result, err
+	}
+
+	for _, f := range data.Features {
+		p := f.Properties
+
+		quakeTime, err := parseTime(p.Time)
+		if err != nil {
+			return result, fmt.Errorf("EMSC event %s: %v", f.ID, err)
+		}
+		// A missing update time just means it hasn't been updated
+		updated, err := parseTime(p.LastUpdate)
+		if err != nil {
+			updated = quakeTime
+		}
+
+		id := p.Unid
+		if id == "" {
+			id = f.ID
+		}
+
+		quake := usgs.Feature{
+			Type:   "Feature",
+			ID:     id,
+			Source: SOURCE,
+			Properties: usgs.Properties{
+				Mag:     p.Mag,
+				Place:   p.Region,
+				Time:    quakeTime,
+				Updated: updated,
+				URL:     EVENTPAGE + id,
+				Net:     p.Auth,
+				Ids:     "," + id + ",",
+				MagType: p.MagType,
+				Type:    eventType(p.EventType),
+			},
+			Geometry: usgs.Geometry{
+				Type:        "Point",
+				Coordinates: []float64{p.Lon, p.Lat},
+			},
+		}
+		if p.Depth != nil {
+			quake.Geometry.Coordinates = append(quake.Geometry.Coordinates, *p.Depth)
+		}
+		if p.Mag != nil {
+			quake.Properties.Title = fmt.Sprintf("M %.1f - %s", *p.Mag, p.Region)
+		} else {
+			quake.Properties.Title = p.Region
+		}
+
+		result.Features = append(result.Features, quake)
+	}
+	result.Metadata.Count = len(result.Features)
+
+	return result, nil
+}
+
+// Parse an EMSC timestamp into milliseconds since the epoch like the USGS uses
+// They're UTC, but don't always say so
+func parseTime(value string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02T15:04:05.999999999", value)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+// Get the USGS name for an EMSC event type
+// EMSC uses the QuakeML codes, eg: ke is a known earthquake
+func eventType(code string) string {
+	switch code {
+	case "ke", "se", "fe":
+		return "earthquake"
+	case "km", "sm", "fm":
+		return "mining explosion"
+	case "kx", "sx", "fx":
+		return "explosion"
+	case "kr", "sr", "fr":
+		return "rockburst"
+	}
+
+	return code
+}
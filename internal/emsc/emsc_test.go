@@ -0,0 +1,164 @@
+package emsc
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Get a UTC time in milliseconds since the epoch like Parse gives them
+func utcMillis(year int, month time.Month, day, hour, min, sec, msec int) int64 {
+	return time.Date(year, month, day, hour, min, sec, msec*int(time.Millisecond), time.UTC).UnixNano() / int64(time.Millisecond)
+}
+
+func TestParse(t *testing.T) {
+	file, err := os.Open("testdata/query.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	feed, err := Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Metadata.Count != 4 || len(feed.Features) != 4 {
+		t.Fatalf("got %d quakes and a count of %d, want 4", len(feed.Features), feed.Metadata.Count)
+	}
+
+	mag := func(m float64) *float64 { return &m }
+	tests := []struct {
+		name        string
+		id          string
+		time        int64
+		updated     int64
+		mag         *float64 // nil if it's missing
+		coordinates []float64
+		eventType   string
+		title       string
+	}{
+		{
+			name:        "everything",
+			id:          "20210304_0000042",
+			time:        utcMillis(2021, 3, 4, 5, 6, 7, 800),
+			updated:     utcMillis(2021, 3, 4, 5, 20, 31, 0),
+			mag:         mag(3.4),
+			coordinates: []float64{26.58, 45.63, 140},
+			eventType:   "earthquake",
+			title:       "M 3.4 - ROMANIA",
+		},
+		{
+			// Times without a zone are UTC
+			name:        "no time zone",
+			id:          "20210304_0000039",
+			time:        utcMillis(2021, 3, 4, 4, 55, 12, 300),
+			updated:     utcMillis(2021, 3, 4, 5, 1, 44, 0),
+			mag:         mag(1.8),
+			coordinates: []float64{13.41, 42.35, 9},
+			eventType:   "mining explosion",
+			title:       "M 1.8 - CENTRAL ITALY",
+		},
+		{
+			// Without an update time it's the time of the quake
+			name:        "no depth or magnitude",
+			id:          "20210304_0000037",
+			time:        utcMillis(2021, 3, 4, 4, 40, 0, 0),
+			updated:     utcMillis(2021, 3, 4, 4, 40, 0, 0),
+			coordinates: []float64{22.91, 38.27},
+			eventType:   "explosion",
+			title:       "GREECE",
+		},
+		{
+			// The feature ID stands in for a missing unid, and codes we don't know are kept
+			name:        "no unid",
+			id:          "20210304_0000031",
+			time:        utcMillis(2021, 3, 4, 4, 22, 58, 100),
+			updated:     utcMillis(2021, 3, 4, 4, 31, 10, 0),
+			mag:         mag(4.6),
+			coordinates: []float64{-71.52, -33.09, 35},
+			eventType:   "ls",
+			title:       "M 4.6 - OFFSHORE VALPARAISO, CHILE",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quake := feed.Features[i]
+			p := quake.Properties
+			if quake.ID != test.id {
+				t.Errorf("ID = %q, want %q", quake.ID, test.id)
+			}
+			if quake.Source != SOURCE {
+				t.Errorf("source = %q, want %q", quake.Source, SOURCE)
+			}
+			if p.Time != test.time || p.Updated != test.updated {
+				t.Errorf("time %d updated %d, want %d and %d", p.Time, p.Updated, test.time, test.updated)
+			}
+			switch {
+			case test.mag == nil && p.Mag != nil:
+				t.Errorf("magnitude = %v, want none", *p.Mag)
+			case test.mag != nil && (p.Mag == nil || *p.Mag != *test.mag):
+				t.Errorf("magnitude = %v, want %v", p.Mag, *test.mag)
+			}
+			if !reflect.DeepEqual(quake.Geometry.Coordinates, test.coordinates) {
+				t.Errorf("coordinates = %v, want %v", quake.Geometry.Coordinates, test.coordinates)
+			}
+			if p.Type != test.eventType {
+				t.Errorf("type = %q, want %q", p.Type, test.eventType)
+			}
+			if p.Title != test.title {
+				t.Errorf("title = %q, want %q", p.Title, test.title)
+			}
+			if p.URL != EVENTPAGE+test.id || p.Ids != ","+test.id+"," {
+				t.Errorf("URL %q and IDs %q don't use %s", p.URL, p.Ids, test.id)
+			}
+		})
+	}
+}
+
+func TestEventType(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"ke", "earthquake"},
+		{"se", "earthquake"},
+		{"fe", "earthquake"},
+		{"km", "mining explosion"},
+		{"sm", "mining explosion"},
+		{"kx", "explosion"},
+		{"fx", "explosion"},
+		{"kr", "rockburst"},
+		{"ls", "ls"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := eventType(test.code); got != test.want {
+			t.Errorf("eventType(%q) = %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"not json", "<html>", "invalid character"},
+		{"bad time", `{"features":[{"id":"a","properties":{"time":"yesterday"}}]}`, `EMSC event a: invalid time "yesterday"`},
+		{"missing time", `{"features":[{"id":"a","properties":{}}]}`, `EMSC event a: invalid time ""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.json))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
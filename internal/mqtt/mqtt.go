@@ -0,0 +1,237 @@
+// Package mqtt is a small MQTT 3.1.1 client that can only publish, which is all we need to
+// hand quakes to home automation, see: https://docs.oasis-open.org/mqtt/mqtt/v3.1.1/mqtt-v3.1.1.html
+package mqtt
+
+import (
+	"bufio"           // Needed to read packets
+	"crypto/tls"      // Needed for brokers that use TLS
+	"encoding/binary" // Needed for the two byte lengths
+	"errors"          // Needed for protocol errors
+	"fmt"             // Needed for errors
+	"io"              // Needed to read whole packets
+	"net"             // Needed to connect to the broker
+	"sync"            // Needed so only one publish uses the connection at a time
+	"time"            // Needed for timeouts
+)
+
+// Packet types, already shifted into the top four bits of the first byte
+const (
+	CONNECT    = 0x10
+	CONNACK    = 0x20
+	PUBLISH    = 0x30
+	PUBACK     = 0x40
+	DISCONNECT = 0xe0
+)
+
+// Why a broker refused a connection, by CONNACK return code
+var refusals = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client ID rejected",
+	3: "server unavailable",
+	4: "bad username or password",
+	5: "not authorized",
+}
+
+// Where and how to connect to a broker
+type Options struct {
+	Addr     string      // host:port
+	TLS      *tls.Config // nil for a plain connection
+	ClientID string
+	Username string
+	Password string
+	Timeout  time.Duration // How long connecting or publishing can take
+}
+
+// A connection to a broker
+// It's safe to use from more than one goroutine
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	nextID  uint16
+}
+
+// Connect to a broker and log in
+// The session is clean and there's no keep alive, so an idle connection that's dropped is only
+// noticed by the next publish failing
+func Dial(options Options) (*Client, error) {
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	var conn net.Conn
+	var err error
+	if options.TLS != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", options.Addr, options.TLS)
+	} else {
+		conn, err = dialer.Dial("tcp", options.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{conn: conn, reader: bufio.NewReader(conn), timeout: options.Timeout}
+	if err := c.connect(options); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Send CONNECT and wait for the broker to accept it
+func (c *Client) connect(options Options) error {
+	// 3.1.1 only allows a password along with a username
+	if options.Password != "" && options.Username == "" {
+		return errors.New("a password can't be sent without a username")
+	}
+
+	// Protocol name and level 4 for 3.1.1, then the flags and a keep alive of 0 to turn it off
+	flags := byte(0x02) // Clean session
+	if options.Username != "" {
+		flags |= 0x80
+	}
+	if options.Password != "" {
+		flags |= 0x40
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags, 0, 0)
+
+	body = appendString(body, options.ClientID)
+	if options.Username != "" {
+		body = appendString(body, options.Username)
+	}
+	if options.Password != "" {
+		body = appendString(body, options.Password)
+	}
+
+	if err := c.write(CONNECT, body); err != nil {
+		return err
+	}
+
+	header, ack, err := c.read()
+	if err != nil {
+		return err
+	}
+	if header&0xf0 != CONNACK || len(ack) != 2 {
+		return errors.New("broker didn't acknowledge the connection")
+	}
+	if ack[1] != 0 {
+		if reason, ok := refusals[ack[1]]; ok {
+			return fmt.Errorf("broker refused the connection: %s", reason)
+		}
+		return fmt.Errorf("broker refused the connection: code %d", ack[1])
+	}
+
+	return nil
+}
+
+// Publish a message at QoS 1 and wait for the broker to acknowledge it
+// Retained messages are kept by the broker and sent to anyone who subscribes later
+func (c *Client) Publish(topic string, payload []byte, retained bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Packet IDs can't be 0
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	id := c.nextID
+
+	header := byte(PUBLISH | 0x02) // QoS 1
+	if retained {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, byte(id>>8), byte(id))
+	body = append(body, payload...)
+
+	if err := c.write(header, body); err != nil {
+		return err
+	}
+
+	// We don't subscribe to anything, so the only thing coming back should be the ack
+	for {
+		header, ack, err := c.read()
+		if err != nil {
+			return err
+		}
+		if header&0xf0 == PUBACK && len(ack) == 2 && binary.BigEndian.Uint16(ack) == id {
+			return nil
+		}
+	}
+}
+
+// Say goodbye to the broker and close the connection
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.write(DISCONNECT, nil)
+
+	return c.conn.Close()
+}
+
+// Write a packet with its remaining length
+func (c *Client) write(header byte, body []byte) error {
+	packet := []byte{header}
+	for length := len(body); ; {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	if c.timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	_, err := c.conn.Write(packet)
+
+	return err
+}
+
+// Read a packet, returning its first byte and everything after the remaining length
+func (c *Client) read() (byte, []byte, error) {
+	if c.timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+
+	header, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := c.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 3 && digit&0x80 != 0 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header, body, nil
+}
+
+// Append a string with its two byte length
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,158 @@
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+// A broker that accepts one connection, records the CONNECT it gets, and acks everything
+type fakeBroker struct {
+	listener net.Listener
+	connect  chan []byte
+	publish  chan []byte
+	code     byte // CONNACK return code
+}
+
+func newFakeBroker(t *testing.T, code byte) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{listener: listener, connect: make(chan []byte, 1), publish: make(chan []byte, 10), code: code}
+	go b.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return b
+}
+
+func (b *fakeBroker) serve() {
+	conn, err := b.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &Client{conn: conn, reader: bufio.NewReader(conn), timeout: time.Second}
+	for {
+		header, body, err := c.read()
+		if err != nil {
+			return
+		}
+		switch header & 0xf0 {
+		case CONNECT:
+			b.connect <- body
+			c.write(CONNACK, []byte{0, b.code})
+		case PUBLISH:
+			b.publish <- body
+			topicLength := int(body[0])<<8 | int(body[1])
+			c.write(PUBACK, body[2+topicLength:4+topicLength])
+		case DISCONNECT:
+			return
+		}
+	}
+}
+
+func TestDialSendsLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		flags    byte
+	}{
+		{"anonymous", "", "", 0x02},
+		{"username only", "quakes", "", 0x82},
+		{"username and password", "quakes", "secret", 0xc2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			broker := newFakeBroker(t, 0)
+			client, err := Dial(Options{Addr: broker.listener.Addr().String(), ClientID: "test", Username: test.username, Password: test.password, Timeout: time.Second})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			connect := <-broker.connect
+			// "MQTT" with its length, then the level, then the flags
+			if got := connect[7]; got != test.flags {
+				t.Errorf("connect flags = %#x, want %#x", got, test.flags)
+			}
+		})
+	}
+}
+
+func TestDialRejectsPasswordWithoutUsername(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	if _, err := Dial(Options{Addr: broker.listener.Addr().String(), Password: "secret", Timeout: time.Second}); err == nil {
+		t.Fatal("Dial with a password and no username worked, want an error")
+	}
+}
+
+func TestDialRefused(t *testing.T) {
+	broker := newFakeBroker(t, 4)
+	_, err := Dial(Options{Addr: broker.listener.Addr().String(), Username: "quakes", Password: "wrong", Timeout: time.Second})
+	if err == nil || err.Error() != "broker refused the connection: bad username or password" {
+		t.Fatalf("Dial error = %v, want bad username or password", err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	client, err := Dial(Options{Addr: broker.listener.Addr().String(), ClientID: "test", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := client.Publish("quakes", []byte(`{"id":"a"}`), true); err != nil {
+			t.Fatalf("publish %d: %v", i, err)
+		}
+		body := <-broker.publish
+		if got, want := string(body[2:8]), "quakes"; got != want {
+			t.Errorf("publish %d: topic = %q, want %q", i, got, want)
+		}
+		if got, want := string(body[10:]), `{"id":"a"}`; got != want {
+			t.Errorf("publish %d: payload = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestWriteRemainingLength(t *testing.T) {
+	tests := []struct {
+		length int
+		want   []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xff, 0x7f}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+	}
+
+	for _, test := range tests {
+		server, client := net.Pipe()
+		c := &Client{conn: client}
+		go func() {
+			c.write(PUBLISH, make([]byte, test.length))
+			client.Close()
+		}()
+
+		reader := bufio.NewReader(server)
+		reader.ReadByte()
+		got := make([]byte, len(test.want))
+		for i := range got {
+			got[i], _ = reader.ReadByte()
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("length %d: remaining length = %x, want %x", test.length, got, test.want)
+				break
+			}
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,299 @@
+package usgs
+
+import (
+	"compress/gzip" // Needed to decompress responses
+	"context"       // Needed to stop fetches on shutdown
+	"encoding/json" // Needed to parse USGS data
+	"errors"        // Needed for the not modified error
+	"fmt"           // Needed for unexpected responses
+	"io"            // Needed to hand responses to the parsers
+	"io/ioutil"     // Needed to read data from the USGS website
+	"math/rand"     // Needed to add jitter to the backoff
+	"net"           // Needed to spot timeouts
+	"net/http"      // Needed to query the USGS website
+	"strconv"       // Needed to parse Retry-After
+	"strings"       // Needed to check the feed format
+	"sync"          // Needed to share the jitter source
+	"time"          // Needed for backoff
+)
+
+const (
+	// What features from the USGS are tagged with
+	SOURCE = "usgs"
+
+	USERAGENT = "EarthquakeCLI/1.0 (+https://github.com/HelixSpiral/EarthquakeCLI)"
+
+	// How many times to retry a request that failed for a reason that might go away
+	MAXRETRIES = 3
+
+	// How much of an unexpected response's body to put in the error
+	MAXSNIPPET = 200
+)
+
+// Returned when the feed hasn't changed since we last fetched it
+var ErrNotModified = errors.New("feed not modified")
+
+// Returned when the server says we're asking too often, with how long it wants us to wait
+// RetryAfter is 0 when the server didn't say, so the usual backoff should be used
+type RateLimitError struct {
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited: %s, retry after %s", e.Status, e.RetryAfter.Round(time.Second))
+	}
+
+	return "rate limited: " + e.Status
+}
+
+// Random source for backoff jitter, shared between fetches
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Fetches feeds from one of the USGS APIs
+type Client struct {
+	http      *http.Client
+	baseURL   string
+	retries   int
+	baseDelay time.Duration
+}
+
+// Validators from the last response, used to ask the USGS to only send the feed if it changed
+// The zero value is ready to use
+type Cache struct {
+	etag         string
+	lastModified string
+}
+
+// Create a client for the API at baseURL
+// The http.Client should have a timeout so a stalled connection can't hang updates forever
+func NewClient(httpClient *http.Client, baseURL string) *Client {
+	return &Client{
+		http:      httpClient,
+		baseURL:   baseURL,
+		retries:   MAXRETRIES,
+		baseDelay: time.Second,
+	}
+}
+
+// Fetch and parse the feed at path, relative to the client's base URL
+// Paths ending in .csv are parsed as CSV, everything else as GeoJSON
+func (c *Client) Get(ctx context.Context, path string, cache *Cache) (Feed, error) {
+	// The summary feeds can be fetched as CSV instead of GeoJSON, which is much smaller
+	parse := ParseGeoJSON
+	if strings.HasSuffix(path, ".csv") {
+		parse = ParseCSV
+	}
+
+	feed, err := c.GetWith(ctx, path, cache, parse)
+	if err != nil {
+		return feed, err
+	}
+
+	for i := range feed.Features {
+		feed.Features[i].Source = SOURCE
+	}
+
+	return feed, nil
+}
+
+// Fetch the feed at path, relative to the client's base URL, and parse it with parse
+// This lets other agencies' feeds be read into the same form as the USGS ones
+// The request is abandoned if ctx is cancelled, and retried a few times if it fails for a transient reason
+// If cache is given, ErrNotModified is returned when the feed hasn't changed since the last call
+func (c *Client) GetWith(ctx context.Context, path string, cache *Cache, parse func(io.Reader) (Feed, error)) (Feed, error) {
+	var feed Feed
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return feed, err
+	}
+	if cache != nil {
+		if cache.etag != "" {
+			req.Header.Set("If-None-Match", cache.etag)
+		}
+		if cache.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cache.lastModified)
+		}
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return feed, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if cache != nil {
+			return feed, ErrNotModified
+		}
+		return feed, unexpectedResponse(resp)
+	case http.StatusNoContent:
+		// FDSN services answer with no content when nothing matches
+		return feed, nil
+	default:
+		return feed, unexpectedResponse(resp)
+	}
+
+	// Maintenance pages come back as HTML, sometimes even with a 200
+	if isHTML(resp) {
+		return feed, unexpectedResponse(resp)
+	}
+
+	body, err := decodedBody(resp)
+	if err != nil {
+		return feed, err
+	}
+
+	feed, err = parse(body)
+	if err != nil {
+		return feed, err
+	}
+
+	// Only remember the validators once we know the response was good
+	if cache != nil {
+		cache.etag = resp.Header.Get("ETag")
+		cache.lastModified = resp.Header.Get("Last-Modified")
+	}
+
+	return feed, nil
+}
+
+// Parse a GeoJSON feed
+// It's decoded as it's read so the big feeds don't have to be held in memory twice
+func ParseGeoJSON(r io.Reader) (Feed, error) {
+	var feed Feed
+
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&feed); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case err == io.EOF:
+			return feed, errors.New("empty feed")
+		case err == io.ErrUnexpectedEOF, errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+			return feed, fmt.Errorf("malformed feed: %w", err)
+		}
+		return feed, fmt.Errorf("reading feed: %v", err)
+	}
+
+	// Anything after the feed means it's not what we think it is
+	if _, err := decoder.Token(); err != io.EOF {
+		return feed, errors.New("malformed feed: unexpected data after the feed")
+	}
+
+	return feed, nil
+}
+
+// Get the response body, decompressing it if the server gzipped it
+// We ask for gzip ourselves so Go's transport leaves it to us, unless it decompressed it anyway
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+
+	body, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzipped response: %v", err)
+	}
+
+	return body, nil
+}
+
+// Check if a response is an HTML page rather than the data we asked for
+func isHTML(resp *http.Response) bool {
+	return strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/html")
+}
+
+// Build an error for a response we can't use, with the status and the start of the body
+// so maintenance pages and the like say what's going on
+// Rate limiting gets a RateLimitError instead so callers can wait as long as they're asked to
+func unexpectedResponse(resp *http.Response) error {
+	if wait := retryAfter(resp); wait > 0 || resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{Status: resp.Status, RetryAfter: wait}
+	}
+
+	var body []byte
+	if decoded, err := decodedBody(resp); err == nil {
+		body, _ = ioutil.ReadAll(io.LimitReader(decoded, MAXSNIPPET))
+	}
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if snippet == "" {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	return fmt.Errorf("unexpected response: %s (%s %q)", resp.Status, resp.Header.Get("Content-Type"), snippet)
+}
+
+// Send a request, retrying timeouts, server errors, and rate limiting with exponential backoff
+// The last response or error is returned once we run out of retries
+func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", USERAGENT)
+	// The month feeds are several MB, gzip cuts that down a lot
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	for attempt := 0; ; attempt++ {
+		// When the server says how long to wait we leave it to the caller to wait that long,
+		// retrying sooner would just get us rate limited again
+		resp, err := c.http.Do(req)
+		if attempt >= c.retries || !retryable(resp, err) || retryAfter(resp) > 0 || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(c.baseDelay, attempt)):
+		}
+	}
+}
+
+// Get how long a 429 or 503 response asks us to wait in its Retry-After header, in seconds or
+// as a date, or 0 if it doesn't say
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0
+	}
+
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// Check if a request failed in a way that's worth retrying
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		netErr, ok := err.(net.Error)
+		return ok && netErr.Timeout()
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// Get how long to wait before the next attempt, doubling each time with up to 50% jitter
+// so lots of clients failing at once don't all retry at the same moment
+func backoff(baseDelay time.Duration, attempt int) time.Duration {
+	delay := baseDelay << uint(attempt)
+
+	jitter.Lock()
+	defer jitter.Unlock()
+
+	return delay + time.Duration(jitter.Int63n(int64(delay)/2+1))
+}
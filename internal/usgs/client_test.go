@@ -0,0 +1,340 @@
+package usgs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Read a recorded feed from testdata
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestParseGeoJSONFixtures(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		count   int
+		check   func(t *testing.T, feed Feed)
+	}{
+		{
+			name:    "empty feature list",
+			fixture: "empty_hour.geojson",
+			count:   0,
+		},
+		{
+			name:    "magnitude",
+			fixture: "all_hour.geojson",
+			count:   4,
+			check: func(t *testing.T, feed Feed) {
+				quake := feed.Features[0]
+				if quake.Properties.Mag == nil || *quake.Properties.Mag != 1.32 {
+					t.Errorf("magnitude = %v, want 1.32", quake.Properties.Mag)
+				}
+				if len(quake.Geometry.Coordinates) != 3 || quake.Geometry.Coordinates[2] != 2.16 {
+					t.Errorf("coordinates = %v, want a depth of 2.16", quake.Geometry.Coordinates)
+				}
+			},
+		},
+		{
+			name:    "null magnitude",
+			fixture: "all_hour.geojson",
+			count:   4,
+			check: func(t *testing.T, feed Feed) {
+				quake := feed.Features[1]
+				if quake.Properties.Mag != nil {
+					t.Errorf("magnitude = %v, want nil", *quake.Properties.Mag)
+				}
+				if quake.Properties.Nst != 0 || quake.Properties.Gap != 0 {
+					t.Errorf("null nst and gap = %d, %v, want 0", quake.Properties.Nst, quake.Properties.Gap)
+				}
+			},
+		},
+		{
+			name:    "null place",
+			fixture: "all_hour.geojson",
+			count:   4,
+			check: func(t *testing.T, feed Feed) {
+				if place := feed.Features[2].Properties.Place; place != "" {
+					t.Errorf("place = %q, want empty", place)
+				}
+			},
+		},
+		{
+			name:    "missing fields",
+			fixture: "all_hour.geojson",
+			count:   4,
+			check: func(t *testing.T, feed Feed) {
+				quake := feed.Features[3]
+				if quake.Properties.Tz != nil || quake.Properties.URL != "" || quake.Properties.Alert != "" {
+					t.Errorf("missing fields = %+v, want them empty", quake.Properties)
+				}
+				if quake.ID != "hv72376922" {
+					t.Errorf("ID = %q, want hv72376922", quake.ID)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			feed, err := ParseGeoJSON(bytes.NewReader(fixture(t, test.fixture)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(feed.Features) != test.count || feed.Metadata.Count != test.count {
+				t.Fatalf("got %d quakes and a count of %d, want %d", len(feed.Features), feed.Metadata.Count, test.count)
+			}
+			if feed.Metadata.Generated != 1614834430000 {
+				t.Errorf("generated = %d, want 1614834430000", feed.Metadata.Generated)
+			}
+			if test.check != nil {
+				test.check(t, feed)
+			}
+		})
+	}
+}
+
+func TestParseGeoJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "", "empty feed"},
+		{"cut off", `{"type":"FeatureCollection","features":[{"type":"Feature"`, "malformed feed"},
+		{"wrong type", `{"type":"FeatureCollection","features":{}}`, "malformed feed"},
+		{"trailing data", `{"type":"FeatureCollection","features":[]} {}`, "unexpected data after the feed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseGeoJSON(strings.NewReader(test.body))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestClientGet(t *testing.T) {
+	allHour := fixture(t, "all_hour.geojson")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != USERAGENT {
+			t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), USERAGENT)
+		}
+
+		switch r.URL.Path {
+		case "/all_hour.geojson":
+			w.Write(allHour)
+		case "/gzipped.geojson":
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write(allHour)
+			gz.Close()
+		case "/all_hour.csv":
+			w.Write([]byte("time,latitude,longitude,depth,mag,id\n2021-03-04T05:01:56.240Z,38.8,-122.8,2.16,1.32,nc73524811\n"))
+		case "/maintenance.geojson":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Down for maintenance</body></html>"))
+		case "/query":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), server.URL)
+	client.retries = 0
+
+	tests := []struct {
+		name    string
+		path    string
+		count   int
+		wantErr string
+	}{
+		{name: "GeoJSON", path: "/all_hour.geojson", count: 4},
+		{name: "gzipped", path: "/gzipped.geojson", count: 4},
+		{name: "CSV", path: "/all_hour.csv", count: 1},
+		{name: "no content", path: "/query", count: 0},
+		{name: "maintenance page", path: "/maintenance.geojson", wantErr: "Down for maintenance"},
+		{name: "not found", path: "/nope.geojson", wantErr: "404 Not Found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			feed, err := client.Get(context.Background(), test.path, nil)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("err = %v, want one mentioning %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(feed.Features) != test.count {
+				t.Fatalf("got %d quakes, want %d", len(feed.Features), test.count)
+			}
+			for _, quake := range feed.Features {
+				if quake.Source != SOURCE {
+					t.Errorf("%s has source %q, want %q", quake.ID, quake.Source, SOURCE)
+				}
+			}
+		})
+	}
+}
+
+func TestClientGetNotModified(t *testing.T) {
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(fixture(t, "all_hour.geojson"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), server.URL)
+	var cache Cache
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", &cache); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", &cache); err != ErrNotModified {
+		t.Errorf("second fetch err = %v, want ErrNotModified", err)
+	}
+	if fetches != 2 {
+		t.Errorf("server got %d requests, want 2", fetches)
+	}
+}
+
+func TestClientGetIfModifiedSince(t *testing.T) {
+	const lastModified = "Thu, 04 Mar 2021 05:07:10 GMT"
+	var broken bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if broken {
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Last-Modified", "Thu, 04 Mar 2021 06:00:00 GMT")
+			w.Write([]byte("<html>Down for maintenance</html>"))
+			return
+		}
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write(fixture(t, "all_hour.geojson"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.Client(), server.URL)
+	client.retries = 0
+	var cache Cache
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", &cache); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", &cache); err != ErrNotModified {
+		t.Fatalf("second fetch err = %v, want ErrNotModified", err)
+	}
+
+	// A bad response doesn't replace the validators from the last good one
+	broken = true
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", &cache); err == nil {
+		t.Fatal("maintenance page worked, want an error")
+	}
+	if cache.lastModified != lastModified {
+		t.Errorf("Last-Modified after a bad response = %q, want %q", cache.lastModified, lastModified)
+	}
+
+	// Without a cache nothing's sent to get a 304 with, so one is unexpected
+	broken = false
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", nil); err != nil {
+		t.Errorf("fetch without a cache: %v", err)
+	}
+}
+
+func TestClientGetBadResponses(t *testing.T) {
+	allHour := fixture(t, "all_hour.geojson")
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name: "503 maintenance page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("<html>\n  <body>Service   Unavailable</body>\n</html>"))
+			},
+			want: `unexpected response: 503 Service Unavailable (text/html "<html> <body>Service Unavailable</body> </html>")`,
+		},
+		{
+			name: "503 without a body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			want: "unexpected response: 503 Service Unavailable",
+		},
+		{
+			name:    "empty body",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			want:    "empty feed",
+		},
+		{
+			name: "truncated JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write(allHour[:len(allHour)/2])
+			},
+			want: "malformed feed: unexpected EOF",
+		},
+		{
+			name: "connection dropped partway",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", strconv.Itoa(len(allHour)))
+				w.Write(allHour[:len(allHour)/2])
+			},
+			want: "unexpected EOF",
+		},
+		{
+			name: "snippets are cut short",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(strings.Repeat("x", 1000)))
+			},
+			want: strings.Repeat("x", MAXSNIPPET) + `")`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.handler)
+			defer server.Close()
+
+			client := NewClient(server.Client(), server.URL)
+			client.retries = 0
+			_, err := client.Get(context.Background(), "/all_hour.geojson", nil)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("err = %v, want one with %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package usgs
+
+import (
+	"encoding/csv" // Needed to read CSV feeds
+	"fmt"          // Needed for errors and titles
+	"io"           // Needed to read the feed
+	"strconv"      // Needed to parse the numbers
+	"time"         // Needed to parse the timestamps
+)
+
+// Event pages aren't in the CSV feeds, but they're always at the same place
+const EVENTPAGE = "https://earthquake.usgs.gov/earthquakes/eventpage/"
+
+// Parse a CSV summary feed into the same form as the GeoJSON feeds
+// The CSV only has some of the properties, the rest are left empty
+// See: https://earthquake.usgs.gov/earthquakes/feed/v1.0/csv.php
+func ParseCSV(r io.Reader) (Feed, error) {
+	feed := Feed{Type: "FeatureCollection"}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return feed, err
+	}
+	if len(records) == 0 {
+		return feed, fmt.Errorf("empty CSV feed")
+	}
+
+	// Look columns up by name so it doesn't matter if USGS reorders them
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, required := range []string{"id", "time", "latitude", "longitude"} {
+		if _, ok := columns[required]; !ok {
+			return feed, fmt.Errorf("CSV feed is missing the %s column", required)
+		}
+	}
+
+	for line, record := range records[1:] {
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		// Numbers that are missing are left empty, ones that are there but don't parse are too,
+		// with a warning so a feed that's changed under us doesn't go unnoticed
+		number := func(name string) (float64, bool) {
+			value := field(name)
+			if value == "" {
+				return 0, false
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				feed.Warnings = append(feed.Warnings, fmt.Sprintf("line %d: invalid %s %q", line+2, name, value))
+				return 0, false
+			}
+			return v, true
+		}
+
+		quakeTime, err := parseCSVTime(field("time"))
+		if err != nil {
+			return feed, fmt.Errorf("line %d of CSV feed: %v", line+2, err)
+		}
+		// A missing updated time just means it hasn't been updated
+		updated, err := parseCSVTime(field("updated"))
+		if err != nil {
+			updated = quakeTime
+		}
+
+		id := field("id")
+		quake := Feature{
+			Type: "Feature",
+			ID:   id,
+			Properties: Properties{
+				Place:   field("place"),
+				Time:    quakeTime,
+				Updated: updated,
+				URL:     EVENTPAGE + id,
+				Status:  field("status"),
+				Net:     field("net"),
+				Ids:     "," + id + ",",
+				MagType: field("magType"),
+				Type:    field("type"),
+			},
+			Geometry: Geometry{Type: "Point"},
+		}
+		nst, _ := number("nst")
+		quake.Properties.Nst = int(nst)
+		quake.Properties.Dmin, _ = number("dmin")
+		quake.Properties.Rms, _ = number("rms")
+		quake.Properties.Gap, _ = number("gap")
+
+		// Coordinates that don't parse are left out rather than putting the quake at 0,0, and the
+		// depth can only follow them since GeoJSON goes longitude, latitude, depth
+		lon, lonOK := number("longitude")
+		lat, latOK := number("latitude")
+		if lonOK && latOK {
+			quake.Geometry.Coordinates = []float64{lon, lat}
+		}
+
+		// Magnitude and depth can be missing, just like in the GeoJSON
+		if mag, ok := number("mag"); ok {
+			quake.Properties.Mag = &mag
+			quake.Properties.Title = fmt.Sprintf("M %.1f - %s", mag, quake.Properties.Place)
+		} else {
+			quake.Properties.Title = quake.Properties.Place
+		}
+		if depth, ok := number("depth"); ok && len(quake.Geometry.Coordinates) == 2 {
+			quake.Geometry.Coordinates = append(quake.Geometry.Coordinates, depth)
+		}
+
+		feed.Features = append(feed.Features, quake)
+	}
+	feed.Metadata.Count = len(feed.Features)
+
+	return feed, nil
+}
+
+// Parse a CSV timestamp into milliseconds since the epoch like the GeoJSON uses
+func parseCSVTime(value string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
@@ -0,0 +1,144 @@
+package usgs
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const csvHeader = "time,latitude,longitude,depth,mag,magType,nst,gap,dmin,rms,net,id,updated,place,type,status\n"
+
+func TestParseCSVCorruptNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		row      string
+		warnings []string
+		check    func(Feature) bool
+	}{
+		{
+			name: "all good",
+			row:  "2021-03-04T05:06:07.890Z,38.8,-122.8,2.5,1.9,md,12,80,0.01,0.03,nc,nc1,2021-03-04T05:10:00.000Z,The Geysers,earthquake,automatic",
+			check: func(f Feature) bool {
+				return f.Properties.Nst == 12 && f.Properties.Gap == 80 && f.Properties.Rms == 0.03
+			},
+		},
+		{
+			name:  "missing numbers aren't warned about",
+			row:   "2021-03-04T05:06:07.890Z,38.8,-122.8,,,md,,,,,nc,nc1,,The Geysers,earthquake,automatic",
+			check: func(f Feature) bool { return f.Properties.Mag == nil && len(f.Geometry.Coordinates) == 2 },
+		},
+		{
+			name:     "bad station numbers",
+			row:      "2021-03-04T05:06:07.890Z,38.8,-122.8,2.5,1.9,md,twelve,80,n/a,0.03,nc,nc1,,The Geysers,earthquake,automatic",
+			warnings: []string{`line 2: invalid nst "twelve"`, `line 2: invalid dmin "n/a"`},
+			check:    func(f Feature) bool { return f.Properties.Nst == 0 && f.Properties.Dmin == 0 && f.Properties.Gap == 80 },
+		},
+		{
+			name:     "bad magnitude",
+			row:      "2021-03-04T05:06:07.890Z,38.8,-122.8,2.5,M2,md,12,80,0.01,0.03,nc,nc1,,The Geysers,earthquake,automatic",
+			warnings: []string{`line 2: invalid mag "M2"`},
+			check:    func(f Feature) bool { return f.Properties.Mag == nil && f.Properties.Title == "The Geysers" },
+		},
+		{
+			name:     "bad latitude leaves the coordinates out",
+			row:      "2021-03-04T05:06:07.890Z,38.8N,-122.8,2.5,1.9,md,12,80,0.01,0.03,nc,nc1,,The Geysers,earthquake,automatic",
+			warnings: []string{`line 2: invalid latitude "38.8N"`},
+			check:    func(f Feature) bool { return len(f.Geometry.Coordinates) == 0 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			feed, err := ParseCSV(strings.NewReader(csvHeader + test.row + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(feed.Features) != 1 {
+				t.Fatalf("got %d quakes, want 1", len(feed.Features))
+			}
+			if !test.check(feed.Features[0]) {
+				t.Errorf("quake = %+v", feed.Features[0])
+			}
+			if strings.Join(feed.Warnings, "|") != strings.Join(test.warnings, "|") {
+				t.Errorf("warnings = %q, want %q", feed.Warnings, test.warnings)
+			}
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{"empty", "", "empty CSV feed"},
+		{"missing column", "time,latitude,depth\n", "CSV feed is missing the id column"},
+		{"bad time", csvHeader + "yesterday,38.8,-122.8,2.5,1.9,md,12,80,0.01,0.03,nc,nc1,,The Geysers,earthquake,automatic\n", `line 2 of CSV feed: invalid time "yesterday"`},
+		{"unbalanced quotes", csvHeader + `2021-03-04T05:06:07.890Z,38.8,-122.8,2.5,1.9,md,12,80,0.01,0.03,nc,nc1,,"The Geysers,earthquake,automatic` + "\n", "extraneous or missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(test.csv))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVNoQuakes(t *testing.T) {
+	feed, err := ParseCSV(strings.NewReader(csvHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Features) != 0 || feed.Metadata.Count != 0 {
+		t.Errorf("got %d quakes and a count of %d, want none", len(feed.Features), feed.Metadata.Count)
+	}
+}
+
+// The CSV and GeoJSON fixtures are the same feed, so everything the CSV has should match
+func TestParseCSVMatchesGeoJSON(t *testing.T) {
+	geojson, err := ParseGeoJSON(bytes.NewReader(fixture(t, "all_hour.geojson")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, err := ParseCSV(bytes.NewReader(fixture(t, "all_hour.csv")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(csv.Warnings) != 0 {
+		t.Errorf("warnings = %q, want none", csv.Warnings)
+	}
+	if len(csv.Features) != len(geojson.Features) {
+		t.Fatalf("CSV has %d quakes, GeoJSON has %d", len(csv.Features), len(geojson.Features))
+	}
+
+	for i, want := range geojson.Features {
+		got := csv.Features[i]
+		if got.ID != want.ID {
+			t.Errorf("quake %d: ID = %q, want %q", i, got.ID, want.ID)
+			continue
+		}
+
+		// Only what's in the CSV, the rest is left empty
+		p, w := got.Properties, want.Properties
+		w.Tz, w.Detail, w.Felt, w.Cdi, w.Mmi, w.Alert, w.Tsunami, w.Sig, w.Code, w.Sources, w.Types = nil, "", 0, 0, 0, "", 0, 0, "", "", ""
+		if w.URL == "" {
+			// The CSV always has an event page, it's always in the same place
+			w.URL = EVENTPAGE + want.ID
+		}
+		if w.Mag == nil || w.Place == "" {
+			// The CSV's titles are built from what it has
+			w.Title = p.Title
+		}
+		if !reflect.DeepEqual(p, w) {
+			t.Errorf("%s: CSV properties = %+v\nwant %+v", got.ID, p, w)
+		}
+		if !reflect.DeepEqual(got.Geometry.Coordinates, want.Geometry.Coordinates) {
+			t.Errorf("%s: CSV coordinates = %v, want %v", got.ID, got.Geometry.Coordinates, want.Geometry.Coordinates)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package usgs
+
+import (
+	"context"       // Needed to stop fetches on shutdown
+	"encoding/json" // Needed to parse the detail
+	"errors"        // Needed for the deleted event error
+	"fmt"           // Needed for unexpected responses
+	"net/http"      // Needed to query the USGS website
+	"net/url"       // Needed to build the event query
+)
+
+// Returned when the event asked for has been deleted or never existed
+var ErrEventGone = errors.New("event deleted or not found")
+
+// The detail GeoJSON for a single quake, which has everything the summary feeds leave out
+// See: https://earthquake.usgs.gov/earthquakes/feed/v1.0/geojson_detail.php
+type Detail struct {
+	ID         string           `json:"id"`
+	Properties DetailProperties `json:"properties"`
+}
+
+type DetailProperties struct {
+	Felt     *int64               `json:"felt"`
+	Cdi      *float64             `json:"cdi"`
+	Mmi      *float64             `json:"mmi"`
+	Alert    string               `json:"alert"`
+	Products map[string][]Product `json:"products"`
+}
+
+// A product contributed for a quake, eg: a shakemap or a moment tensor
+// The first product of each type is the preferred one
+type Product struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Code       string            `json:"code"`
+	Source     string            `json:"source"`
+	Status     string            `json:"status"`
+	Properties map[string]string `json:"properties"`
+}
+
+// Fetch the detail for a quake from the URL in its Detail property
+// The URL is used as is rather than being relative to the client's base URL
+func (c *Client) GetDetail(ctx context.Context, url string) (Detail, error) {
+	var detail Detail
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return detail, err
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return detail, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || isHTML(resp) {
+		return detail, unexpectedResponse(resp)
+	}
+
+	body, err := decodedBody(resp)
+	if err != nil {
+		return detail, err
+	}
+
+	if err := json.NewDecoder(body).Decode(&detail); err != nil {
+		return detail, fmt.Errorf("malformed detail: %w", err)
+	}
+
+	return detail, nil
+}
+
+// Fetch the latest solution for a single event from the FDSN API at the client's base URL
+// The feature has the same properties as in the summary feeds, so it can be shown the same way
+// ErrEventGone is returned, wrapped with the status, if USGS says the event is deleted or unknown
+func (c *Client) GetEvent(ctx context.Context, id string) (Feature, error) {
+	var event Feature
+	query := url.Values{"eventid": {id}, "format": {"geojson"}}
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return event, err
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return event, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusConflict, http.StatusGone:
+		// FDSN answers 409 for deleted events, other services use 404 or 410
+		return event, fmt.Errorf("%w: %s", ErrEventGone, resp.Status)
+	default:
+		return event, unexpectedResponse(resp)
+	}
+	if isHTML(resp) {
+		return event, unexpectedResponse(resp)
+	}
+
+	body, err := decodedBody(resp)
+	if err != nil {
+		return event, err
+	}
+
+	if err := json.NewDecoder(body).Decode(&event); err != nil {
+		return event, fmt.Errorf("malformed event: %w", err)
+	}
+	event.Source = SOURCE
+
+	return event, nil
+}
+
+// Get a property from the preferred product of a type, eg: maxmmi from the shakemap
+func (d Detail) ProductProperty(productType, name string) (string, bool) {
+	products := d.Properties.Products[productType]
+	if len(products) == 0 {
+		return "", false
+	}
+
+	value, ok := products[0].Properties[name]
+
+	return value, ok
+}
@@ -0,0 +1,68 @@
+// Package usgs fetches and parses earthquake data from the USGS GeoJSON feeds and FDSN event API
+package usgs
+
+// Structs for holding the GeoJson information
+// From the USGS: https://tools.ietf.org/html/rfc7946
+type Feed struct {
+	Type     string    `json:"type"`
+	Metadata Metadata  `json:"metadata"`
+	Features []Feature `json:"features"`
+
+	// Values that were in the feed but couldn't be read and were left out, eg: line 3: invalid rms "n/a"
+	// This isn't in the feeds, it's filled in when they're parsed
+	Warnings []string `json:"-"`
+}
+
+type Metadata struct {
+	Generated int64  `json:"generated"`
+	Url       string `json:"url"`
+	Title     string `json:"title"`
+	Api       string `json:"api"`
+	Count     int    `json:"count"`
+	Status    int    `json:"status"`
+}
+
+type Feature struct {
+	Type       string     `json:"type"`
+	Properties Properties `json:"properties"`
+	Geometry   Geometry   `json:"geometry"`
+	ID         string     `json:"id"`
+
+	// Which agency the feature came from, eg: usgs or emsc
+	// This isn't in the USGS feeds, it's filled in when they're parsed
+	Source string `json:"source,omitempty"`
+}
+
+type Properties struct {
+	Mag     *float64 `json:"mag"` // nil if the magnitude isn't known yet
+	Place   string   `json:"place"`
+	Time    int64    `json:"time"`
+	Updated int64    `json:"updated"`
+	Tz      *int64   `json:"tz"` // Minutes from UTC at the epicenter, often missing
+	URL     string   `json:"url"`
+	Detail  string   `json:"detail"`
+	Felt    int64    `json:"felt"`
+	Cdi     float64  `json:"cdi"`
+	Mmi     float64  `json:"mmi"`
+	Alert   string   `json:"alert"`
+	Status  string   `json:"status"`
+	Tsunami int      `json:"tsunami"`
+	Sig     int      `json:"sig"`
+	Net     string   `json:"net"`
+	Code    string   `json:"code"`
+	Ids     string   `json:"ids"`
+	Sources string   `json:"sources"`
+	Types   string   `json:"types"`
+	Nst     int      `json:"nst"`
+	Dmin    float64  `json:"dmin"`
+	Rms     float64  `json:"rms"`
+	Gap     float64  `json:"gap"`
+	MagType string   `json:"magType"`
+	Type    string   `json:"type"`
+	Title   string   `json:"title"`
+}
+
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
@@ -0,0 +1,188 @@
+package usgs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Start a server that answers with status the first failures times, then with the fixture
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(fixture(t, "all_hour.geojson"))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+// Make a client that retries without waiting long
+func fastClient(server *httptest.Server) *Client {
+	client := NewClient(server.Client(), server.URL)
+	client.baseDelay = time.Millisecond
+
+	return client
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		status   int
+		requests int32
+		wantErr  bool
+	}{
+		{"first time", 0, 0, 1, false},
+		{"server error then fine", 2, http.StatusBadGateway, 3, false},
+		{"too many requests then fine", 1, http.StatusTooManyRequests, 2, false},
+		{"server error every time", 10, http.StatusInternalServerError, MAXRETRIES + 1, true},
+		{"client errors aren't retried", 10, http.StatusNotFound, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := flakyServer(t, test.failures, test.status)
+			feed, err := fastClient(server).Get(context.Background(), "/all_hour.geojson", nil)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("err = %v, want an error: %v", err, test.wantErr)
+			}
+			if !test.wantErr && len(feed.Features) != 4 {
+				t.Errorf("got %d quakes, want 4", len(feed.Features))
+			}
+			if got := atomic.LoadInt32(requests); got != test.requests {
+				t.Errorf("server got %d requests, want %d", got, test.requests)
+			}
+		})
+	}
+}
+
+func TestRetriesTimeouts(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write(fixture(t, "all_hour.geojson"))
+	}))
+	defer server.Close()
+
+	httpClient := server.Client()
+	httpClient.Timeout = 50 * time.Millisecond
+	client := NewClient(httpClient, server.URL)
+	client.baseDelay = time.Millisecond
+
+	if _, err := client.Get(context.Background(), "/all_hour.geojson", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("server got %d requests, want 2", got)
+	}
+}
+
+func TestRetriesStopWhenCancelled(t *testing.T) {
+	server, requests := flakyServer(t, 10, http.StatusServiceUnavailable)
+	client := NewClient(server.Client(), server.URL)
+	client.baseDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Get(ctx, "/all_hour.geojson", nil); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want the context's error", err)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("server got %d requests, want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		delay := time.Second << uint(attempt)
+		for i := 0; i < 50; i++ {
+			if got := backoff(time.Second, attempt); got < delay || got > delay+delay/2 {
+				t.Fatalf("attempt %d: backoff = %v, want between %v and %v", attempt, got, delay, delay+delay/2)
+			}
+		}
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		requests   int32
+		wait       time.Duration // The RateLimitError's RetryAfter
+		limited    bool
+	}{
+		// The caller waits as long as it's asked to, so there's no retrying straight away
+		{"429 with Retry-After", http.StatusTooManyRequests, "120", 1, 2 * time.Minute, true},
+		{"503 with Retry-After", http.StatusServiceUnavailable, "30", 1, 30 * time.Second, true},
+		// Without it the usual backoff applies
+		{"429 without Retry-After", http.StatusTooManyRequests, "", MAXRETRIES + 1, 0, true},
+		{"503 without Retry-After", http.StatusServiceUnavailable, "", MAXRETRIES + 1, 0, false},
+		{"429 with a bad Retry-After", http.StatusTooManyRequests, "soon", MAXRETRIES + 1, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			_, err := fastClient(server).Get(context.Background(), "/all_hour.geojson", nil)
+			var rateLimit *RateLimitError
+			if errors.As(err, &rateLimit) != test.limited {
+				t.Fatalf("err = %v, want rate limited: %v", err, test.limited)
+			}
+			if test.limited && rateLimit.RetryAfter != test.wait {
+				t.Errorf("RetryAfter = %v, want %v", rateLimit.RetryAfter, test.wait)
+			}
+			if got := atomic.LoadInt32(&requests); got != test.requests {
+				t.Errorf("server got %d requests, want %d", got, test.requests)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		value  string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"seconds", http.StatusTooManyRequests, "90", 90 * time.Second, 90 * time.Second},
+		{"date", http.StatusServiceUnavailable, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+		{"date in the past", http.StatusTooManyRequests, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		{"zero", http.StatusTooManyRequests, "0", 0, 0},
+		{"negative", http.StatusTooManyRequests, "-5", 0, 0},
+		{"missing", http.StatusTooManyRequests, "", 0, 0},
+		{"not rate limited", http.StatusBadGateway, "90", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: test.status, Header: http.Header{}}
+			resp.Header.Set("Retry-After", test.value)
+			if got := retryAfter(resp); got < test.min || got > test.max {
+				t.Errorf("retryAfter = %v, want between %v and %v", got, test.min, test.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"     // Needed to lay out the help
+	"strings" // Needed to build the help
+
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+// A key and what it does, every key the app handles is declared as one of these so the help
+// always matches what the keys actually do
+type keyBinding struct {
+	label       string      // How the keys are shown in the help, eg: "y / Y"
+	keys        []tcell.Key // Special keys that trigger it, eg: Enter
+	runes       []rune      // Characters that trigger it
+	description string
+	global      bool // Works on any page, not just while the table has focus
+	action      func(event *tcell.EventKey)
+}
+
+// The key bindings, in the order they're listed in the help
+type keyMap []keyBinding
+
+// Check if a key press triggers this binding
+func (b keyBinding) matches(event *tcell.EventKey) bool {
+	if event.Key() == tcell.KeyRune {
+		for _, r := range b.runes {
+			if r == event.Rune() {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, key := range b.keys {
+		if key == event.Key() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run the binding for a key press, global picks between the bindings that work anywhere and
+// the ones that only work from the table
+// Returns false if no binding matched so the key can be handled as usual
+func (k keyMap) handle(event *tcell.EventKey, global bool) bool {
+	for _, binding := range k {
+		if binding.global == global && binding.matches(event) {
+			binding.action(event)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Build the help text listing every binding, with the keys lined up in a column
+func (k keyMap) help() string {
+	width := 0
+	for _, binding := range k {
+		if len(binding.label) > width {
+			width = len(binding.label)
+		}
+	}
+
+	var lines []string
+	for _, binding := range k {
+		lines = append(lines, fmt.Sprintf(" [yellow]%-*s[-]  %s", width, binding.label, binding.description))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Get the size of a box that fits the help with its border
+func (k keyMap) helpSize() (int, int) {
+	width := 0
+	for _, line := range strings.Split(k.help(), "\n") {
+		if w := tview.TaggedStringWidth(line); w > width {
+			width = w
+		}
+	}
+
+	return width + 3, len(k) + 2
+}
+
+// Center p in a box of the given size, for showing over the top of another page
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+}
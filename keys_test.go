@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+func TestKeyMapHandle(t *testing.T) {
+	var ran []string
+	keys := keyMap{
+		{label: "q / Esc", keys: []tcell.Key{tcell.KeyEscape}, runes: []rune{'q'}, global: true, action: func(*tcell.EventKey) { ran = append(ran, "quit") }},
+		{label: "r", runes: []rune{'r'}, action: func(*tcell.EventKey) { ran = append(ran, "refresh") }},
+	}
+
+	tests := []struct {
+		name    string
+		event   *tcell.EventKey
+		global  bool
+		handled bool
+		ran     string
+	}{
+		{"q quits anywhere", tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), true, true, "quit"},
+		{"Esc quits anywhere", tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), true, true, "quit"},
+		{"q isn't a table key", tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), false, false, ""},
+		{"r from the table", tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone), false, true, "refresh"},
+		{"r isn't global", tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone), true, false, ""},
+		{"other keys are left alone", tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone), true, false, ""},
+	}
+
+	for _, test := range tests {
+		ran = nil
+		if handled := keys.handle(test.event, test.global); handled != test.handled {
+			t.Errorf("%s: handled = %v, want %v", test.name, handled, test.handled)
+		}
+		got := ""
+		if len(ran) > 0 {
+			got = ran[0]
+		}
+		if got != test.ran {
+			t.Errorf("%s: ran %q, want %q", test.name, got, test.ran)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"strings" // Needed to list the layouts
+)
+
+// Terminals narrower than this get the narrow layout when -layout is auto
+const NARROWWIDTH = 100
+
+// How the table is laid out, -layout picks one and 'w' switches between wide and narrow
+const (
+	layoutAuto = iota
+	layoutWide
+	layoutNarrow
+)
+
+// Names for -layout, by layout
+var layoutNames = []string{
+	layoutAuto:   "auto",
+	layoutWide:   "wide",
+	layoutNarrow: "narrow",
+}
+
+// Columns shown in the narrow layout, the place is cut down to whatever room is left
+var narrowColumns = []int{columnTime, columnMagnitude, columnLocation}
+
+// Parse the name of a layout
+func parseLayout(name string) (int, error) {
+	for layout, layoutName := range layoutNames {
+		if layoutName == name {
+			return layout, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid layout %q: must be one of %s", name, strings.Join(layoutNames, ", "))
+}
+
+// Pick the layout for a screen this wide, this is called before every draw so it follows resizes
+func (q *quakeTable) fitLayout(screenWidth int) {
+	switch q.layout {
+	case layoutWide:
+		q.setNarrow(false)
+	case layoutNarrow:
+		q.setNarrow(true)
+	default:
+		q.setNarrow(screenWidth < NARROWWIDTH)
+	}
+}
+
+// Switch between the wide and narrow layouts, which stops following the terminal size
+func (q *quakeTable) toggleLayout() {
+	if q.narrow {
+		q.layout = layoutWide
+	} else {
+		q.layout = layoutNarrow
+	}
+	q.setNarrow(!q.narrow)
+}
+
+// Show the narrow layout without cell borders, or the wide one with every column
+// The table is rebuilt from the rows, so nothing is lost going back and forth
+func (q *quakeTable) setNarrow(narrow bool) {
+	if narrow == q.narrow {
+		return
+	}
+	q.narrow = narrow
+
+	selectedID := q.selectedID()
+	q.columns = q.wideColumns
+	if narrow {
+		q.columns = narrowColumns
+	}
+
+	// Cells from columns the other layout had would be left behind otherwise
+	q.table.Clear()
+	q.table.SetBorders(!narrow)
+	q.render()
+	q.selectID(selectedID)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/gdamore/tcell"
+)
+
+// Quitting cancels the context, which has to abandon a fetch that's stuck waiting on the server
+// so the update goroutine can return
+func TestFeedSourceStopsOnShutdown(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	source := feedSource(usgs.NewClient(server.Client(), server.URL), "/all_hour.geojson")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := source(ctx)
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("the fetch worked, want it cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fetch kept going after shutdown")
+	}
+}
+
+func TestNullFieldsFromFixture(t *testing.T) {
+	feed, err := readFeedFile("internal/usgs/testdata/all_hour.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quakes := make(map[string]usgs.Feature)
+	for _, quake := range feed.Features {
+		quakes[quake.ID] = quake
+	}
+	scale := colorScale{{0, tcell.ColorYellow}}
+
+	tests := []struct {
+		id    string
+		mag   string
+		place string
+		color tcell.Color
+	}{
+		{"nc73524811", "1.32", "6km NW of The Geysers, CA", tcell.ColorYellow},
+		{"ak0212ynt1cn", "—", "Southern Alaska", tcell.ColorWhite},   // Null magnitude
+		{"us7000dflf", "4.60", "37.822, 142.361", tcell.ColorYellow}, // Null place
+	}
+
+	for _, test := range tests {
+		quake, ok := quakes[test.id]
+		if !ok {
+			t.Fatalf("%s isn't in the fixture", test.id)
+		}
+		cells := formatRow(quake)
+		if got := cells[columnMagnitude]; got != test.mag {
+			t.Errorf("%s: magnitude cell = %q, want %q", test.id, got, test.mag)
+		}
+		if got := cells[columnLocation]; got != test.place {
+			t.Errorf("%s: location cell = %q, want %q", test.id, got, test.place)
+		}
+		if got := magnitudeColor(scale, quake); got != test.color {
+			t.Errorf("%s: color = %v, want %v", test.id, got, test.color)
+		}
+	}
+
+	// Without a place or coordinates all that's left is the ID
+	if got := quakePlace(usgs.Feature{ID: "us1234"}); got != "us1234" {
+		t.Errorf("quakePlace with nothing = %q, want the ID", got)
+	}
+}
+
+func TestCSVAndGeoJSONRowsMatch(t *testing.T) {
+	geojson, err := readFeedFile("internal/usgs/testdata/all_hour.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, err := readFeedFile("internal/usgs/testdata/all_hour.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(csv.Features) != len(geojson.Features) {
+		t.Fatalf("CSV has %d quakes, GeoJSON has %d", len(csv.Features), len(geojson.Features))
+	}
+
+	for i := range geojson.Features {
+		want, got := formatRow(geojson.Features[i]), formatRow(csv.Features[i])
+		for _, column := range []int{columnID, columnMagnitude, columnDepth, columnLocation, columnReviewed} {
+			if got[column] != want[column] {
+				t.Errorf("%s: CSV %s = %q, GeoJSON has %q", geojson.Features[i].ID, tableColumns[column].name, got[column], want[column])
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"  // Needed to shut the server down
+	"fmt"      // Needed to write the metrics
+	"math"     // Needed to find the largest quake
+	"net"      // Needed to listen before the TUI starts
+	"net/http" // Needed to serve the metrics
+	"sync"     // Needed to share the metrics with the server
+	"time"     // Needed to time fetches
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// How long to wait for requests in progress when shutting down a server
+const SERVERSHUTDOWN = 2 * time.Second
+
+// Counters for how fetching is going, served in the Prometheus text format
+// It's safe to use from more than one goroutine, and a nil *metrics ignores everything
+type metrics struct {
+	mu            sync.Mutex
+	fetches       int64
+	fetchErrors   int64
+	eventsSeen    int64
+	tracked       int
+	largest       float64 // NaN when there's nothing with a magnitude
+	fetchDuration time.Duration
+	downloaded    func() int64 // Bytes downloaded so far, if we know
+}
+
+func newMetrics() *metrics {
+	return &metrics{largest: math.NaN()}
+}
+
+// Wrap a source so every fetch is counted and timed
+// A feed that hasn't changed isn't an error
+func (m *metrics) meter(source quakeSource) quakeSource {
+	if m == nil {
+		return source
+	}
+
+	return func(ctx context.Context) (usgs.Feed, error) {
+		started := time.Now()
+		feed, err := source(ctx)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.fetches++
+		if err != nil && err != usgs.ErrNotModified {
+			m.fetchErrors++
+		}
+		m.fetchDuration = time.Since(started)
+
+		return feed, err
+	}
+}
+
+// Count a quake we hadn't seen before
+func (m *metrics) seen() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eventsSeen++
+}
+
+// Record how many quakes we're tracking and the largest of them
+// This is called by whichever goroutine owns the quake list
+func (m *metrics) track(quakeList map[string]usgs.Feature) {
+	if m == nil {
+		return
+	}
+
+	largest := math.NaN()
+	for _, quake := range quakeList {
+		if mag, ok := quakeMagnitude(quake); ok && quake.Properties.Status != "deleted" && !(mag <= largest) {
+			largest = mag
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tracked = len(quakeList)
+	m.largest = largest
+}
+
+// Write the metrics in the Prometheus text format
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metric := func(name, kind, help string, value interface{}) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	metric("earthquakecli_fetches_total", "counter", "Feed fetches attempted.", m.fetches)
+	metric("earthquakecli_fetch_errors_total", "counter", "Feed fetches that failed.", m.fetchErrors)
+	metric("earthquakecli_events_seen_total", "counter", "Quakes seen for the first time.", m.eventsSeen)
+	metric("earthquakecli_events_tracked", "gauge", "Quakes currently tracked.", m.tracked)
+	metric("earthquakecli_last_fetch_duration_seconds", "gauge", "How long the last fetch took.", m.fetchDuration.Seconds())
+	metric("earthquakecli_largest_magnitude", "gauge", "Largest magnitude of the tracked quakes.", m.largest)
+	if m.downloaded != nil {
+		metric("earthquakecli_downloaded_bytes_total", "counter", "Bytes downloaded, before decompressing.", m.downloaded())
+	}
+}
+
+// Start serving the metrics at /metrics on addr
+func serveMetrics(addr string, m *metrics) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+
+	return listenAndServe(addr, mux)
+}
+
+// Start serving handler on addr in the background
+// We listen straight away so a bad address is reported before anything else starts
+func listenAndServe(addr string, handler http.Handler) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+
+	return server, nil
+}
+
+// Stop a server, giving any request in progress a moment to finish
+func closeServer(server *http.Server) {
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), SERVERSHUTDOWN)
+	defer cancel()
+	server.Shutdown(ctx)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/tls"  // Needed to set up certificate checks
+	"crypto/x509" // Needed to add CA certificates and spot certificate errors
+	"errors"      // Needed to dig into fetch errors
+	"fmt"         // Needed for errors
+	"io/ioutil"   // Needed to read the CA bundle
+	"net"         // Needed to spot proxy errors
+	"net/http"    // Needed to build the client
+	"time"        // Needed for the timeout
+)
+
+// Build the HTTP client every fetch goes through, using the proxy from HTTP_PROXY, HTTPS_PROXY,
+// and NO_PROXY like most tools do
+// caCert is a PEM bundle to trust along with the system CAs, for networks behind an intercepting
+// proxy, and insecure turns certificate checks off altogether
+func newHTTPClient(timeout time.Duration, caCert string, insecure bool) (*http.Client, error) {
+	config, err := newTLSConfig(caCert, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = config
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// Build the TLS settings for -cacert and -insecure, shared by everything we connect to
+func newTLSConfig(caCert string, insecure bool) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if caCert == "" {
+		return config, nil
+	}
+
+	pem, err := ioutil.ReadFile(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read CA bundle: %v", err)
+	}
+
+	// The system CAs are kept so everything else still works, we can only add to them
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("invalid CA bundle %q: no PEM certificates in it", caCert)
+	}
+	config.RootCAs = roots
+
+	return config, nil
+}
+
+// Explain a fetch error, saying what to try when it's a proxy or certificate problem since
+// the errors Go gives for those don't make it obvious
+func describeFetchError(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "TLS certificate from an unknown authority, if you're behind a proxy that intercepts HTTPS pass its CA with -cacert: " + err.Error()
+	case errors.As(err, &hostname):
+		return "TLS certificate is for another host, a proxy may be intercepting HTTPS: " + err.Error()
+	case errors.As(err, &invalid):
+		return "TLS certificate is invalid: " + err.Error()
+	case errors.As(err, &opErr) && opErr.Op == "proxyconnect":
+		return "couldn't connect to the proxy, check HTTPS_PROXY: " + err.Error()
+	}
+
+	return err.Error()
+}
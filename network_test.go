@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientCACert(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // The failed handshakes are expected
+	server.StartTLS()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The test server's certificate is its own CA, like a proxy's private CA
+	caCert := filepath.Join(dir, "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(caCert, bundle, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The certificate is for 127.0.0.1 and example.com, not localhost
+	localhost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name     string
+		url      string
+		caCert   string
+		insecure bool
+		want     string // In the described error, empty if the fetch should work
+	}{
+		{"with the CA", server.URL, caCert, false, ""},
+		{"without the CA", server.URL, "", false, "pass its CA with -cacert"},
+		{"another host", localhost, caCert, false, "a proxy may be intercepting HTTPS"},
+		{"insecure", server.URL, "", true, ""},
+		{"insecure another host", localhost, "", true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := newHTTPClient(time.Second, test.caCert, test.insecure)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := client.Get(test.url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("fetch failed: %v", err)
+			case test.want != "" && err == nil:
+				t.Error("fetch worked, want it to fail")
+			case test.want != "" && !strings.Contains(describeFetchError(err), test.want):
+				t.Errorf("error = %q, want it to mention %q", describeFetchError(err), test.want)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		caCert string
+		want   string
+	}{
+		{filepath.Join(dir, "missing.pem"), "couldn't read CA bundle"},
+		{notPEM, "no PEM certificates in it"},
+	}
+
+	for _, test := range tests {
+		if _, err := newTLSConfig(test.caCert, false); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error = %v, want it to mention %q", filepath.Base(test.caCert), err, test.want)
+		}
+	}
+}
+
+func TestDescribeFetchErrorProxy(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&net.OpError{Op: "proxyconnect", Net: "tcp", Err: errors.New("connection refused")}, "check HTTPS_PROXY"},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, "dial tcp: connection refused"},
+		{errors.New("unexpected response: 500"), "unexpected response: 500"},
+	}
+
+	for _, test := range tests {
+		if got := describeFetchError(test.err); !strings.Contains(got, test.want) {
+			t.Errorf("describeFetchError(%v) = %q, want it to mention %q", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"         // Needed for printing
+	"os/exec"     // Needed to run the notifier for this OS
+	"runtime"     // Needed to pick the notifier for this OS
+	"strings"     // Needed to escape notification text
+	"sync/atomic" // Needed to check if we're muted
+	"time"        // Needed for the notification time zone
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Alerts raised when we see big quakes, and the log of every quake we see
+type quakeAlerts struct {
+	notifyAbove float64
+	bellAbove   float64
+	alertSig    int            // Quakes this significant alert whatever their magnitude, 0 turns it off
+	sound       string         // Played along with the bell, if it's set
+	ring        func()         // Rings the terminal bell
+	muted       *int32         // Set to 1 to silence the bell and sound, shared with the UI
+	webhook     *webhook       // nil if there's no webhook
+	mqtt        *mqttPublisher // nil if there's no MQTT broker
+	slack       *chatWebhook   // nil if there's no Slack webhook
+	discord     *chatWebhook   // nil if there's no Discord webhook
+	events      *eventLog      // nil if we aren't logging
+	archive     *quakeArchive  // nil if we aren't archiving
+	metrics     *metrics       // nil if we aren't serving metrics
+	debug       *debugLog      // nil without -debug
+	location    *time.Location // Time zone for notification times
+}
+
+// Raise any alerts for a quake that's new or was updated
+// previous is nil if we haven't seen the quake before
+func (a quakeAlerts) check(quake usgs.Feature, previous *usgs.Feature) {
+	a.events.log(quake)
+	a.archive.save(quake)
+	if previous == nil {
+		a.metrics.seen()
+	}
+
+	// A heavily felt quake under a city can matter more than its magnitude suggests
+	significant := a.alertSig > 0 && crossedSignificance(a.alertSig, quake, previous)
+
+	if crossedThreshold(a.notifyAbove, quake, previous) || (a.notifyAbove > 0 && significant) {
+		go sendNotification(quakeNotification(quake, a.location))
+	}
+
+	if a.webhook != nil && a.webhook.wants(quake, previous) {
+		a.webhook.send(quake)
+	}
+
+	if a.mqtt != nil && a.mqtt.wants(quake, previous) {
+		a.mqtt.send(quake)
+	}
+
+	if a.slack != nil && a.slack.wants(quake, previous) {
+		a.slack.send(quake)
+	}
+	if a.discord != nil && a.discord.wants(quake, previous) {
+		a.discord.send(quake)
+	}
+
+	if (crossedThreshold(a.bellAbove, quake, previous) || (a.bellAbove > 0 && significant)) && atomic.LoadInt32(a.muted) == 0 {
+		if a.ring != nil {
+			a.ring()
+		}
+		if a.sound != "" {
+			go playSound(a.sound)
+		}
+	}
+}
+
+// Send anything that waits for the end of a refresh, ie: chat messages, which are summed up when
+// a refresh has lots of quakes
+func (a quakeAlerts) flush() {
+	a.slack.flush()
+	a.discord.flush()
+}
+
+// Check if a quake has just reached a magnitude threshold, either because it's
+// new or because it was revised up past it, so we only alert once per quake
+// Quakes without a magnitude never cross a threshold
+func crossedThreshold(threshold float64, quake usgs.Feature, previous *usgs.Feature) bool {
+	mag, ok := quakeMagnitude(quake)
+	if threshold <= 0 || !ok || mag < threshold {
+		return false
+	}
+	if previous == nil {
+		return true
+	}
+
+	previousMag, ok := quakeMagnitude(*previous)
+	return !ok || previousMag < threshold
+}
+
+// Check if a quake has just reached a significance threshold, like crossedThreshold does for magnitudes
+func crossedSignificance(threshold int, quake usgs.Feature, previous *usgs.Feature) bool {
+	if quake.Properties.Sig < threshold {
+		return false
+	}
+
+	return previous == nil || previous.Properties.Sig < threshold
+}
+
+// Build the title and message for a quake notification
+// Quakes that alerted on significance alone might not have a magnitude
+func quakeNotification(quake usgs.Feature, location *time.Location) (string, string) {
+	title := "Significant earthquake"
+	if mag, ok := quakeMagnitude(quake); ok {
+		title = fmt.Sprintf("M%.1f earthquake", mag)
+	}
+	message := fmt.Sprintf("%s\n%s", quakePlace(quake), formatTime(quake.Properties.Time, location))
+	if quake.Properties.Tsunami == 1 {
+		message += "\nTsunami flag set"
+	}
+
+	return title, message
+}
+
+// Send a desktop notification using whatever this OS provides
+func sendNotification(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", windowsToast(title, message))
+	default:
+		cmd = exec.Command("notify-send", title, message)
+	}
+
+	return cmd.Run()
+}
+
+// Play an audio file using whatever this OS provides
+func playSound(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("afplay", path)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "(New-Object Media.SoundPlayer '"+strings.Replace(path, "'", "''", -1)+"').PlaySync()")
+	default:
+		cmd = exec.Command("paplay", path)
+	}
+
+	return cmd.Run()
+}
+
+// Quote a string for AppleScript
+func appleScriptString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+
+	return `"` + s + `"`
+}
+
+// Build a PowerShell script that shows a toast notification
+func windowsToast(title, message string) string {
+	quote := func(s string) string {
+		return "'" + strings.Replace(s, "'", "''", -1) + "'"
+	}
+
+	return `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null;` +
+		`$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02);` +
+		`$text = $template.GetElementsByTagName('text');` +
+		`$text.Item(0).AppendChild($template.CreateTextNode(` + quote(title) + `)) > $null;` +
+		`$text.Item(1).AppendChild($template.CreateTextNode(` + quote(message) + `)) > $null;` +
+		`$toast = [Windows.UI.Notifications.ToastNotification]::new($template);` +
+		`[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('EarthquakeCLI').Show($toast)`
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context" // Needed to cancel fetches on shutdown
+	"fmt"     // Needed for printing
+	"io"      // Needed to write the output
+	"os"      // Needed to report fetch errors
+	"strings" // Needed to keep tabs out of the output
+	"time"    // Needed to format the quake times and follow the feed
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Print quakes as tab separated lines instead of running the TUI
+// With follow set we keep checking for new quakes until ctx is cancelled
+func runPlain(ctx context.Context, w io.Writer, source quakeSource, filter quakeFilter, alerts quakeAlerts, follow bool, refresh time.Duration) error {
+	quakeList := make(map[string]usgs.Feature)
+
+	if err := printQuakes(ctx, w, quakeList, source, filter, alerts); err != nil {
+		return err
+	}
+	if !follow {
+		return nil
+	}
+
+	updateTicker := time.NewTicker(refresh)
+	defer updateTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-updateTicker.C:
+			// Keep following if a later fetch fails, it'll probably work next time
+			err := printQuakes(ctx, w, quakeList, source, filter, alerts)
+			if err != nil && err != usgs.ErrNotModified && ctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, "fetch failed:", describeFetchError(err))
+			}
+		}
+	}
+}
+
+// Fetch the quakes and print any new or updated ones, oldest first
+func printQuakes(ctx context.Context, w io.Writer, quakeList map[string]usgs.Feature, source quakeSource, filter quakeFilter, alerts quakeAlerts) error {
+	data, err := source(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes, _ := diffQuakes(data, quakeList, filter, alerts)
+
+	// Quakes that are gone were already printed, there's no taking them back
+	for _, change := range changes {
+		if change.action == changeRemove {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, formatPlain(change.quake)); err != nil {
+			return err
+		}
+	}
+
+	alerts.metrics.track(quakeList)
+
+	return nil
+}
+
+// Format a quake as a tab separated line: ID, time, magnitude, depth, place
+func formatPlain(quake usgs.Feature) string {
+	fields := []string{
+		quake.ID,
+		formatScriptTime(quake.Properties.Time),
+		formatMagnitude(quake),
+		formatDepth(quake.Geometry),
+		quakePlace(quake),
+	}
+	for i := range fields {
+		fields[i] = strings.Replace(fields[i], "\t", " ", -1)
+	}
+
+	return strings.Join(fields, "\t")
+}
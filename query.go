@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context" // Needed to cancel fetches on shutdown
+	"fmt"     // Needed for printing
+	"net/url" // Needed to build the query string
+	"strconv" // Needed to parse the bounding box
+	"strings" // Needed to split the bounding box
+	"time"    // Needed to parse the start and end times
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+const (
+	FDSNAPI = "https://earthquake.usgs.gov/fdsnws/event/1/query"
+
+	// The most events the FDSN API will return in one request
+	FDSNLIMIT = 20000
+)
+
+// Options for querying the FDSN event API
+type queryOptions struct {
+	start        time.Time
+	end          time.Time
+	minMagnitude float64
+	maxDepth     float64
+	bbox         *geoBox
+	near         *geoRadius
+}
+
+// Build the FDSN query string and title for the given options, the title's times are in location
+// The offset and limit are added per page by querySource
+func getQuery(options queryOptions, location *time.Location) (string, string) {
+	params := url.Values{}
+	params.Set("format", "geojson")
+	params.Set("orderby", "time")
+	params.Set("starttime", options.start.UTC().Format(time.RFC3339))
+	params.Set("endtime", options.end.UTC().Format(time.RFC3339))
+	if options.minMagnitude > 0 {
+		params.Set("minmagnitude", strconv.FormatFloat(options.minMagnitude, 'f', -1, 64))
+	}
+	if options.maxDepth > 0 {
+		params.Set("maxdepth", strconv.FormatFloat(options.maxDepth, 'f', -1, 64))
+	}
+	if box := options.bbox; box != nil {
+		// The API takes longitudes up to 360 for boxes that cross the antimeridian
+		maxLon := box.maxLon
+		if box.wraps() {
+			maxLon += 360
+		}
+		params.Set("minlatitude", strconv.FormatFloat(box.minLat, 'f', -1, 64))
+		params.Set("minlongitude", strconv.FormatFloat(box.minLon, 'f', -1, 64))
+		params.Set("maxlatitude", strconv.FormatFloat(box.maxLat, 'f', -1, 64))
+		params.Set("maxlongitude", strconv.FormatFloat(maxLon, 'f', -1, 64))
+	}
+	if options.near != nil {
+		params.Set("latitude", strconv.FormatFloat(options.near.center.lat, 'f', -1, 64))
+		params.Set("longitude", strconv.FormatFloat(options.near.center.lon, 'f', -1, 64))
+		params.Set("maxradiuskm", strconv.FormatFloat(options.near.radius, 'f', -1, 64))
+	}
+
+	title := fmt.Sprintf("USGS Earthquakes, %s to %s", options.start.In(location).Format("2006-01-02 15:04"), options.end.In(location).Format("2006-01-02 15:04"))
+
+	return "?" + params.Encode(), title
+}
+
+// Get a source that pages through the FDSN API until every matching event is fetched
+func querySource(client *usgs.Client, query string) quakeSource {
+	return func(ctx context.Context) (usgs.Feed, error) {
+		var all usgs.Feed
+
+		// The FDSN offset is 1 based
+		for offset := 1; ; offset += FDSNLIMIT {
+			page, err := client.Get(ctx, fmt.Sprintf("%s&limit=%d&offset=%d", query, FDSNLIMIT, offset), nil)
+			if err != nil {
+				return all, err
+			}
+
+			all.Type = page.Type
+			all.Metadata = page.Metadata
+			all.Features = append(all.Features, page.Features...)
+
+			if len(page.Features) < FDSNLIMIT {
+				break
+			}
+		}
+		all.Metadata.Count = len(all.Features)
+
+		return all, nil
+	}
+}
+
+// Parse a query time, either a full RFC3339 timestamp or just a date
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %q: expected a date like 2006-01-02 or an RFC3339 timestamp", value)
+	}
+
+	return t, nil
+}
+
+// Parse a bounding box in the form minLat,minLon,maxLat,maxLon
+// A minLon above maxLon makes a box that crosses the antimeridian, eg: 50,170,60,-170 for the Aleutians
+func parseBoundingBox(value string) (*geoBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid bounding box %q: expected minLat,minLon,maxLat,maxLon", value)
+	}
+
+	bbox := make([]float64, 4)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bounding box %q: %q is not a number", value, part)
+		}
+		bbox[i] = v
+	}
+
+	switch {
+	case bbox[0] < -90 || bbox[2] > 90 || bbox[0] >= bbox[2]:
+		return nil, fmt.Errorf("invalid bounding box %q: latitudes must be between -90 and 90 with min below max", value)
+	case bbox[1] < -180 || bbox[1] > 180 || bbox[3] < -180 || bbox[3] > 180 || bbox[1] == bbox[3]:
+		return nil, fmt.Errorf("invalid bounding box %q: longitudes must be between -180 and 180 and not the same", value)
+	}
+
+	return &geoBox{minLat: bbox[0], minLon: bbox[1], maxLat: bbox[2], maxLon: bbox[3]}, nil
+}
+
+// Validate the query flags and turn them into query options
+func getQueryOptions(start, end string, minMagnitude, maxDepth float64, bbox *geoBox, near *geoRadius) (queryOptions, error) {
+	var err error
+	options := queryOptions{
+		minMagnitude: minMagnitude,
+		maxDepth:     maxDepth,
+		bbox:         bbox,
+		near:         near,
+		end:          time.Now(),
+	}
+
+	if options.start, err = parseQueryTime(start); err != nil {
+		return options, err
+	}
+	if end != "" {
+		if options.end, err = parseQueryTime(end); err != nil {
+			return options, err
+		}
+	}
+	if !options.start.Before(options.end) {
+		return options, fmt.Errorf("invalid query: -start must be before -end")
+	}
+
+	// The API takes either a rectangle or a circle, not both
+	if bbox != nil && near != nil {
+		return options, fmt.Errorf("invalid query: -bbox and -near can't be used together")
+	}
+
+	return options, nil
+}
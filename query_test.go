@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBoundingBox(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *geoBox
+	}{
+		{"30,-125,42,-114", &geoBox{minLat: 30, minLon: -125, maxLat: 42, maxLon: -114}},
+		{"50,170,60,-170", &geoBox{minLat: 50, minLon: 170, maxLat: 60, maxLon: -170}},
+		{" -90 , -180 , 90 , 180 ", &geoBox{minLat: -90, minLon: -180, maxLat: 90, maxLon: 180}},
+		{"0,200,10,210", nil},
+		{"0,-10,10,-200", nil},
+		{"0,-190,10,10", nil},
+		{"0,10,10,190", nil},
+		{"-91,0,10,10", nil},
+		{"0,0,91,10", nil},
+		{"10,0,0,10", nil},
+		{"0,10,10,10", nil},
+		{"0,0,10", nil},
+		{"0,0,10,east", nil},
+	}
+
+	for _, test := range tests {
+		got, err := parseBoundingBox(test.value)
+		switch {
+		case test.want == nil && err == nil:
+			t.Errorf("parseBoundingBox(%q) = %+v, want an error", test.value, *got)
+		case test.want != nil && err != nil:
+			t.Errorf("parseBoundingBox(%q) failed: %v", test.value, err)
+		case test.want != nil && *got != *test.want:
+			t.Errorf("parseBoundingBox(%q) = %+v, want %+v", test.value, *got, *test.want)
+		}
+	}
+}
+
+func TestGetQueryTitle(t *testing.T) {
+	options := queryOptions{
+		start: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		end:   time.Date(2021, 3, 2, 12, 30, 0, 0, time.UTC),
+	}
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		location *time.Location
+		want     string
+	}{
+		{time.UTC, "USGS Earthquakes, 2021-03-01 00:00 to 2021-03-02 12:30"},
+		{tokyo, "USGS Earthquakes, 2021-03-01 09:00 to 2021-03-02 21:30"},
+	}
+
+	for _, test := range tests {
+		query, title := getQuery(options, test.location)
+		if title != test.want {
+			t.Errorf("title in %s = %q, want %q", test.location, title, test.want)
+		}
+
+		// The API is always asked in UTC
+		if !strings.Contains(query, "starttime=2021-03-01T00%3A00%3A00Z") {
+			t.Errorf("query in %s = %q, want the start in UTC", test.location, query)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"         // Needed to hand the file to the parsers
+	"context"       // Needed to match quakeSource
+	"encoding/json" // Needed to spot errors with an offset
+	"errors"        // Needed to unwrap parse errors
+	"fmt"           // Needed for errors
+	"io/ioutil"     // Needed to read the file
+	"sort"          // Needed to put the quakes in time order
+	"strconv"       // Needed to parse the replay speed
+	"strings"       // Needed to check the file format and trim the speed
+	"time"          // Needed to work out what's been replayed
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// How often the table checks for replayed quakes, there's no server to be polite to
+const REPLAYTICK = time.Second
+
+// Read a saved feed from a file, files ending in .csv are parsed as CSV, everything else as GeoJSON
+// Parse errors say where in the file the problem is
+func readFeedFile(path string) (usgs.Feed, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return usgs.Feed{}, err
+	}
+
+	if strings.HasSuffix(path, ".csv") {
+		feed, err := usgs.ParseCSV(bytes.NewReader(data))
+		if err != nil {
+			return feed, fmt.Errorf("%s: %v", path, err)
+		}
+		return tagFeed(feed), nil
+	}
+
+	feed, err := usgs.ParseGeoJSON(bytes.NewReader(data))
+	if err != nil {
+		return feed, fileError(path, data, err)
+	}
+
+	return tagFeed(feed), nil
+}
+
+// Tag quakes without a source as USGS ones, since that's the schema the file is in
+func tagFeed(feed usgs.Feed) usgs.Feed {
+	for i := range feed.Features {
+		if feed.Features[i].Source == "" {
+			feed.Features[i].Source = usgs.SOURCE
+		}
+	}
+
+	return feed
+}
+
+// Add the line and column to JSON errors that know their offset into the file
+func fileError(path string, data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return fmt.Errorf("%s:%d:%d (offset %d): %v", path, line, column, offset, err)
+}
+
+// Get a source that always returns the quakes from a file, newest first like the USGS feeds
+func fileSource(feed usgs.Feed) quakeSource {
+	sort.SliceStable(feed.Features, func(i, j int) bool {
+		return feed.Features[i].Properties.Time > feed.Features[j].Properties.Time
+	})
+
+	return func(ctx context.Context) (usgs.Feed, error) {
+		return feed, nil
+	}
+}
+
+// Get a source that hands out the quakes from a file as if they were arriving live, speed times
+// faster than they really happened
+// The clock starts at the first quake on the first fetch, and each fetch returns every quake
+// that's happened by then, newest first like the USGS feeds
+func replaySource(feed usgs.Feed, speed float64) quakeSource {
+	quakes := make([]usgs.Feature, len(feed.Features))
+	copy(quakes, feed.Features)
+	sort.SliceStable(quakes, func(i, j int) bool {
+		return quakes[i].Properties.Time < quakes[j].Properties.Time
+	})
+
+	var started time.Time
+	return func(ctx context.Context) (usgs.Feed, error) {
+		if len(quakes) == 0 {
+			return feed, nil
+		}
+		if started.IsZero() {
+			started = time.Now()
+		}
+
+		elapsed := time.Since(started).Seconds() * speed
+		now := quakes[0].Properties.Time + int64(elapsed*1000)
+		arrived := sort.Search(len(quakes), func(i int) bool {
+			return quakes[i].Properties.Time > now
+		})
+
+		replayed := usgs.Feed{Type: feed.Type, Metadata: feed.Metadata}
+		replayed.Metadata.Count = arrived
+		replayed.Metadata.Generated = now
+		for i := arrived - 1; i >= 0; i-- {
+			replayed.Features = append(replayed.Features, quakes[i])
+		}
+
+		return replayed, nil
+	}
+}
+
+// Parse a replay speed like 60x or 60
+func parseReplaySpeed(value string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(value), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid replay speed %q: must be a positive number like 60x", value)
+	}
+
+	return speed, nil
+}
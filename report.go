@@ -0,0 +1,130 @@
+package main
+
+import (
+	"embed"         // Needed to build the template into the binary
+	"fmt"           // Needed to format the colors
+	"html/template" // Needed to write the report
+	"os"            // Needed to create the report file
+	"time"          // Needed for the generated time
+
+	"github.com/gdamore/tcell"
+)
+
+// The report template, built into the binary so the report works wherever it's run from
+//
+//go:embed templates/report.html
+var reportFiles embed.FS
+
+var reportTemplate = template.Must(template.ParseFS(reportFiles, "templates/report.html"))
+
+// Everything the report template needs, taken from the table so it can be written in the background
+type reportData struct {
+	Title     string
+	Generated string
+	Headers   []string
+	Rows      []reportRow
+	Styles    []reportStyle
+}
+
+// A quake in the report, with the CSS class for its magnitude band
+type reportRow struct {
+	Class   string
+	URL     string
+	Cells   []reportCell
+	Tsunami bool
+	Deleted bool
+}
+
+type reportCell struct {
+	Text string
+	ID   bool
+}
+
+// The color for the quakes in a magnitude band
+type reportStyle struct {
+	Class string
+	Color template.CSS
+}
+
+// Take a snapshot of the quakes shown in the table for the report, with the same columns and colors
+// Times are always absolute since relative ones would be wrong by the time anyone reads it
+func (q *quakeTable) report(title string) reportData {
+	data := reportData{
+		Title:     title,
+		Generated: time.Now().In(q.location).Format("2006-01-02 15:04:05 MST"),
+	}
+
+	// The bands are the color scale's, with anything below the first threshold in green like the table
+	data.Styles = append(data.Styles, reportStyle{Class: "mag-0", Color: cssColor(tcell.ColorGreen)})
+	for i, threshold := range q.colors {
+		data.Styles = append(data.Styles, reportStyle{Class: fmt.Sprintf("mag-%d", i+1), Color: cssColor(threshold.color)})
+	}
+
+	// The report isn't short of room, so it always has every column the wide layout does
+	for _, column := range q.wideColumns {
+		data.Headers = append(data.Headers, tableColumns[column].header)
+	}
+
+	for _, row := range q.shown {
+		class := "mag-none"
+		if mag, ok := quakeMagnitude(row.quake); ok {
+			band := 0
+			for i, threshold := range q.colors {
+				if mag >= threshold.magnitude {
+					band = i + 1
+				}
+			}
+			class = fmt.Sprintf("mag-%d", band)
+		}
+
+		report := reportRow{
+			Class:   class,
+			URL:     row.quake.Properties.URL,
+			Tsunami: row.quake.Properties.Tsunami == 1,
+			Deleted: row.quake.Properties.Status == "deleted",
+		}
+		for _, column := range q.wideColumns {
+			text := row.cells[column]
+			switch column {
+			case columnTime:
+				text = formatTime(row.quake.Properties.Time, q.location)
+			case columnUpdated:
+				text = formatTime(row.quake.Properties.Updated, q.location)
+			case columnDistance:
+				text = q.distanceText(row)
+			case columnCoordinates:
+				text = q.coordinatesText(row)
+			case columnMagnitude:
+				text += q.revisionText(row)
+			case columnLocation:
+				if row.region != "" {
+					text = "📍 " + row.region + " · " + text
+				}
+			}
+			report.Cells = append(report.Cells, reportCell{Text: text, ID: column == columnID})
+		}
+		data.Rows = append(data.Rows, report)
+	}
+
+	return data
+}
+
+// Get a tcell color as a CSS one
+func cssColor(color tcell.Color) template.CSS {
+	return template.CSS(fmt.Sprintf("#%06x", color.Hex()))
+}
+
+// Write a report to an HTML file
+func writeReport(path string, data reportData) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := reportTemplate.Execute(file, data); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
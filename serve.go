@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"       // Needed to cancel fetches on shutdown
+	"encoding/json" // Needed to write the responses
+	"fmt"           // Needed to report fetch errors
+	"net/http"      // Needed to serve the API
+	"os"            // Needed to report fetch errors
+	"strings"       // Needed to get the ID from the path
+	"time"          // Needed for the refresh ticker
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Serve every quake at /events, newest first, one quake as a GeoJSON feature at /events/{id},
+// and whether the last fetch worked at /healthz
+func storeHandler(store *quakeStore) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) { serveEvents(w, r, store) })
+	mux.HandleFunc("/events/", func(w http.ResponseWriter, r *http.Request) { serveEvent(w, r, store) })
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { serveHealth(w, r, store) })
+
+	return mux
+}
+
+func serveEvents(w http.ResponseWriter, r *http.Request, store *quakeStore) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	quakes := store.snapshot().quakes
+	exported := make([]exportedQuake, 0, len(quakes))
+	for _, quake := range quakes {
+		exported = append(exported, exportQuake(quake))
+	}
+
+	writeJSONResponse(w, http.StatusOK, exported)
+}
+
+func serveEvent(w http.ResponseWriter, r *http.Request, store *quakeStore) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/events/")
+
+	quake, ok := store.get(id)
+	if !ok {
+		http.Error(w, "no quake with ID "+id, http.StatusNotFound)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, quake)
+}
+
+// The health check fails while fetches are failing, so a supervisor can tell the data is going stale
+func serveHealth(w http.ResponseWriter, r *http.Request, store *quakeStore) {
+	snapshot := store.snapshot()
+	health := struct {
+		Status      string `json:"status"`
+		Error       string `json:"error,omitempty"`
+		LastUpdated string `json:"lastUpdated,omitempty"`
+		Tracked     int    `json:"tracked"`
+	}{Status: "ok", Tracked: len(snapshot.quakes)}
+	if !snapshot.lastUpdated.IsZero() {
+		health.LastUpdated = snapshot.lastUpdated.UTC().Format(time.RFC3339)
+	}
+
+	code := http.StatusOK
+	if snapshot.err != nil {
+		health.Status = "failing"
+		health.Error = snapshot.err.Error()
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSONResponse(w, code, health)
+}
+
+// Write a value as a JSON response
+func writeJSONResponse(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(value)
+}
+
+// Keep fetching quakes and serve them over HTTP instead of running the TUI, until ctx is cancelled
+func runServer(ctx context.Context, addr string, source quakeSource, filter quakeFilter, alerts quakeAlerts, refresh time.Duration) error {
+	store := &quakeStore{}
+	server, err := listenAndServe(addr, storeHandler(store))
+	if err != nil {
+		return err
+	}
+	defer closeServer(server)
+
+	quakeList := make(map[string]usgs.Feature)
+	fetch := func() {
+		data, err := source(ctx)
+		if err == nil {
+			diffQuakes(data, quakeList, filter, alerts)
+			alerts.metrics.track(quakeList)
+		}
+		if err != nil && err != usgs.ErrNotModified && ctx.Err() == nil {
+			fmt.Fprintln(os.Stderr, "fetch failed:", describeFetchError(err))
+		}
+		store.publish(quakeList, err)
+	}
+
+	fetch()
+	updateTicker := time.NewTicker(refresh)
+	defer updateTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-updateTicker.C:
+			fetch()
+		}
+	}
+}
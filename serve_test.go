@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Make a store like the server has after a fetch, with the quakes that got past the filter
+func servedStore(filter quakeFilter, quakes ...usgs.Feature) *quakeStore {
+	quakeList := make(map[string]usgs.Feature)
+	diffQuakes(usgs.Feed{Features: quakes}, quakeList, filter, quakeAlerts{})
+	store := &quakeStore{}
+	store.publish(quakeList, nil)
+
+	return store
+}
+
+func TestServeEvents(t *testing.T) {
+	store := servedStore(quakeFilter{minMagnitude: 3}, testQuake("old", 4, 1000), testQuake("new", 5, 2000), testQuake("small", 2, 3000))
+	handler := storeHandler(store)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		want   []string // IDs in the response, newest first
+	}{
+		{http.MethodGet, "/events", http.StatusOK, []string{"new", "old"}},
+		{http.MethodGet, "/events/old", http.StatusOK, []string{"old"}},
+		{http.MethodGet, "/events/small", http.StatusNotFound, nil}, // Filtered out, so it was never tracked
+		{http.MethodGet, "/events/missing", http.StatusNotFound, nil},
+		{http.MethodPost, "/events", http.StatusMethodNotAllowed, nil},
+		{http.MethodDelete, "/events/old", http.StatusMethodNotAllowed, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+			if recorder.Code != test.code {
+				t.Fatalf("code = %d, want %d: %s", recorder.Code, test.code, recorder.Body)
+			}
+			if test.code != http.StatusOK {
+				return
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+
+			var ids []string
+			if strings.HasPrefix(test.path, "/events/") {
+				var quake usgs.Feature
+				if err := json.NewDecoder(recorder.Body).Decode(&quake); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, quake.ID)
+			} else {
+				var quakes []exportedQuake
+				if err := json.NewDecoder(recorder.Body).Decode(&quakes); err != nil {
+					t.Fatal(err)
+				}
+				for _, quake := range quakes {
+					ids = append(ids, quake.ID)
+				}
+			}
+			if !equalStrings(ids, test.want) {
+				t.Errorf("quakes = %v, want %v", ids, test.want)
+			}
+		})
+	}
+}
+
+func TestServeEventsEmpty(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	storeHandler(&quakeStore{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	// An empty array rather than null, so scripts can loop over it
+	if got := strings.TrimSpace(recorder.Body.String()); got != "[]" {
+		t.Errorf("body = %s, want []", got)
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	failing := servedStore(quakeFilter{}, testQuake("a", 4, 1000))
+	failing.publish(nil, errors.New("connection refused"))
+
+	tests := []struct {
+		name    string
+		store   *quakeStore
+		code    int
+		status  string
+		tracked int
+	}{
+		{"before the first fetch", &quakeStore{}, http.StatusOK, "ok", 0},
+		{"fetching", servedStore(quakeFilter{}, testQuake("a", 4, 1000)), http.StatusOK, "ok", 1},
+		{"not modified", func() *quakeStore {
+			store := servedStore(quakeFilter{}, testQuake("a", 4, 1000))
+			store.publish(map[string]usgs.Feature{"a": testQuake("a", 4, 1000)}, usgs.ErrNotModified)
+			return store
+		}(), http.StatusOK, "ok", 1},
+		{"failing", failing, http.StatusServiceUnavailable, "failing", 1}, // The quakes are kept
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			storeHandler(test.store).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if recorder.Code != test.code {
+				t.Errorf("code = %d, want %d", recorder.Code, test.code)
+			}
+
+			var health struct {
+				Status      string `json:"status"`
+				Error       string `json:"error"`
+				LastUpdated string `json:"lastUpdated"`
+				Tracked     int    `json:"tracked"`
+			}
+			if err := json.NewDecoder(recorder.Body).Decode(&health); err != nil {
+				t.Fatal(err)
+			}
+			if health.Status != test.status || health.Tracked != test.tracked {
+				t.Errorf("health = %+v, want status %q tracking %d", health, test.status, test.tracked)
+			}
+			if (health.Error != "") != (test.status == "failing") {
+				t.Errorf("error = %q with status %q", health.Error, health.Status)
+			}
+			if (health.LastUpdated != "") != (test.tracked > 0) {
+				t.Errorf("lastUpdated = %q, want it set once a fetch has worked", health.LastUpdated)
+			}
+		})
+	}
+}
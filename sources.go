@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context" // Needed to cancel fetches on shutdown
+	"fmt"     // Needed for errors
+	"net/url" // Needed to build the query string
+	"strconv" // Needed to format the query numbers
+	"strings" // Needed to split the source list
+	"time"    // Needed to work out the start time
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/emsc"
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// The most events we ask the EMSC for at once
+const EMSCLIMIT = 2000
+
+// Agencies we can get quakes from
+var knownAgencies = map[string]bool{
+	usgs.SOURCE: true,
+	emsc.SOURCE: true,
+}
+
+// Parse a comma separated list of agencies, eg: usgs,emsc
+func parseSources(value string) (map[string]bool, error) {
+	sources := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !knownAgencies[name] {
+			return nil, fmt.Errorf("invalid source %q: must be usgs, emsc, or both separated by a comma", name)
+		}
+		sources[name] = true
+	}
+
+	return sources, nil
+}
+
+// Get a source that fetches the quakes from the EMSC over the last window
+// Their service doesn't do conditional requests, so every fetch gets everything
+func emscSource(client *usgs.Client, window time.Duration, minMagnitude float64) quakeSource {
+	return func(ctx context.Context) (usgs.Feed, error) {
+		params := url.Values{}
+		params.Set("format", "json")
+		params.Set("orderby", "time")
+		params.Set("limit", strconv.Itoa(EMSCLIMIT))
+		params.Set("starttime", time.Now().Add(-window).UTC().Format("2006-01-02T15:04:05"))
+		if minMagnitude > 0 {
+			params.Set("minmag", strconv.FormatFloat(minMagnitude, 'f', -1, 64))
+		}
+
+		return client.GetWith(ctx, "?"+params.Encode(), nil, emsc.Parse)
+	}
+}
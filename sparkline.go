@@ -0,0 +1,82 @@
+package main
+
+import (
+	"time" // Needed to bucket the quakes by when they happened
+)
+
+// How much time each bar of the sparkline covers
+const SPARKLINEBUCKET = 10 * time.Minute
+
+// Narrowest the sparkline is worth showing, any less and there's no trend to see
+const MINSPARKLINE = 6
+
+// Bars of the sparkline from the fewest quakes to the most
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Count quakes into n buckets of the given size, the last one ending at end
+// Quakes before the first bucket or after end aren't counted
+func bucketCounts(times []time.Time, end time.Time, size time.Duration, n int) []int {
+	counts := make([]int, n)
+	start := end.Add(-time.Duration(n) * size)
+	for _, t := range times {
+		if t.Before(start) || !t.Before(end) {
+			continue
+		}
+		counts[int(t.Sub(start)/size)]++
+	}
+
+	return counts
+}
+
+// Draw counts as a sparkline, empty buckets get the lowest bar and the busiest gets the highest
+func sparkline(counts []int) string {
+	most := 0
+	for _, count := range counts {
+		if count > most {
+			most = count
+		}
+	}
+
+	bars := make([]rune, len(counts))
+	for i, count := range counts {
+		level := 0
+		if count > 0 {
+			// Rounded up so a single quake still stands out from an empty bucket
+			level = (count*(len(sparkBlocks)-1) + most - 1) / most
+		}
+		bars[i] = sparkBlocks[level]
+	}
+
+	return string(bars)
+}
+
+// Get a sparkline of how many quakes are shown in each 10 minutes, the most recent on the right
+// It covers back to the oldest quake, or as far as fits in width columns, and is empty if there's
+// no room for it
+func (q *quakeTable) sparkline(width int) string {
+	if len(q.shown) == 0 {
+		return ""
+	}
+
+	// Buckets line up with the clock so they don't shift between refreshes
+	end := time.Now().Truncate(SPARKLINEBUCKET).Add(SPARKLINEBUCKET)
+	oldest := end
+	times := make([]time.Time, 0, len(q.shown))
+	for _, row := range q.shown {
+		t := fromMillis(row.quake.Properties.Time)
+		if t.Before(oldest) {
+			oldest = t
+		}
+		times = append(times, t)
+	}
+
+	n := int(end.Sub(oldest)/SPARKLINEBUCKET) + 1
+	if n > width {
+		n = width
+	}
+	if n < MINSPARKLINE {
+		return ""
+	}
+
+	return sparkline(bucketCounts(times, end, SPARKLINEBUCKET, n))
+}
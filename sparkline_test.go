@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBucketCounts(t *testing.T) {
+	end := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	minutes := func(ago ...int) []time.Time {
+		var times []time.Time
+		for _, m := range ago {
+			times = append(times, end.Add(-time.Duration(m)*time.Minute))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  []int // Oldest bucket first
+	}{
+		{"none", nil, []int{0, 0, 0}},
+		{"one in each", minutes(25, 15, 5), []int{1, 1, 1}},
+		{"swarm", minutes(9, 8, 2, 1, 25), []int{1, 0, 4}},
+		{"start of the first bucket counts", minutes(30), []int{1, 0, 0}},
+		{"start of a bucket is in that bucket", minutes(20, 10), []int{0, 1, 1}},
+		{"too old", minutes(31, 60), []int{0, 0, 0}},
+		{"end isn't counted", minutes(0, -5), []int{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := bucketCounts(test.times, end, 10*time.Minute, 3)
+			if len(got) != len(test.want) {
+				t.Fatalf("counts = %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("counts = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSparklineBlocks(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{nil, ""},
+		{[]int{0, 0, 0}, "▁▁▁"},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		{[]int{3, 3}, "██"},
+		{[]int{0, 1, 100}, "▁▂█"}, // A single quake still stands out from none
+		{[]int{50, 100}, "▅█"},
+	}
+
+	for _, test := range tests {
+		if got := sparkline(test.counts); got != test.want {
+			t.Errorf("sparkline(%v) = %q, want %q", test.counts, got, test.want)
+		}
+	}
+}
+
+func TestQuakeTableSparkline(t *testing.T) {
+	// The table's buckets end at the end of the current 10 minutes
+	end := time.Now().Truncate(SPARKLINEBUCKET).Add(SPARKLINEBUCKET)
+	row := func(id string, ago time.Duration) quakeRow {
+		return quakeRow{quake: testQuake(id, 3, millis(end.Add(-ago)))}
+	}
+	rows := []quakeRow{
+		row("a", 5*time.Minute),
+		row("b", 6*time.Minute),
+		row("c", 95*time.Minute),
+	}
+
+	tests := []struct {
+		name  string
+		shown []quakeRow
+		width int
+		want  string
+	}{
+		{"back to the oldest quake", rows, 80, "▅▁▁▁▁▁▁▁▁█"},
+		{"cut to the width", rows, 6, "▁▁▁▁▁█"},
+		{"no room", rows, MINSPARKLINE - 1, ""},
+		{"too short to be a trend", rows[:2], 80, ""},
+		{"nothing shown", nil, 80, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Only the quakes that are shown count, so filters apply to it too
+			q := &quakeTable{shown: test.shown}
+			got := q.sparkline(test.width)
+			if time.Now().Truncate(SPARKLINEBUCKET).Add(SPARKLINEBUCKET) != end {
+				t.Skip("the clock moved on to the next bucket")
+			}
+			if got != test.want {
+				t.Errorf("sparkline = %q, want %q", got, test.want)
+			}
+			if utf8.RuneCountInString(got) > test.width {
+				t.Errorf("sparkline is %d wide, more than %d", utf8.RuneCountInString(got), test.width)
+			}
+		})
+	}
+}
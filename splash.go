@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"  // Needed to format the message
+	"time" // Needed to turn the spinner
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/rivo/tview"
+)
+
+// How often the spinner turns while we wait for the first fetch
+const SPINNERTICK = 100 * time.Millisecond
+
+// Frames of the spinner, in order
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Shown in place of the table until the first fetch finishes, so a slow feed doesn't just look
+// like an empty table
+// Everything but newSplash must be called from the tview event loop
+type splash struct {
+	view    *tview.TextView
+	box     tview.Primitive // The view centered in the space the table takes
+	layout  *tview.Flex
+	body    tview.Primitive // What the splash stands in for
+	title   string
+	frame   int
+	failed  bool // The last fetch failed, so the error is shown instead of the spinner
+	showing bool
+	stop    chan struct{}
+}
+
+// Create the splash for a feed, it isn't shown until show is called
+func newSplash(title string) *splash {
+	s := &splash{
+		view:  tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter),
+		title: title,
+		stop:  make(chan struct{}),
+	}
+	s.box = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(s.view, 3, 0, false).
+		AddItem(nil, 0, 1, false)
+	s.draw()
+
+	return s
+}
+
+// Put the splash where body is in the layout, and turn the spinner until it's hidden
+func (s *splash) show(app *tview.Application, layout *tview.Flex, body tview.Primitive) {
+	s.layout, s.body, s.showing = layout, body, true
+	layout.ResizeItem(body, 0, 0)
+	layout.ResizeItem(s.box, 0, 1)
+
+	go func() {
+		ticker := time.NewTicker(SPINNERTICK)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				app.QueueUpdateDraw(s.spin)
+			}
+		}
+	}()
+}
+
+// Turn the spinner, it stops while an error is shown
+func (s *splash) spin() {
+	if !s.showing || s.failed {
+		return
+	}
+
+	s.frame = (s.frame + 1) % len(spinnerFrames)
+	s.draw()
+}
+
+// Go back to the spinner as a fetch starts
+func (s *splash) fetching() {
+	if !s.showing || !s.failed {
+		return
+	}
+
+	s.failed = false
+	s.draw()
+}
+
+// Swap the table in once a fetch works, or show why it didn't
+func (s *splash) fetched(err error) {
+	if !s.showing {
+		return
+	}
+
+	if err != nil && err != usgs.ErrNotModified {
+		s.failed = true
+		s.view.SetText(fmt.Sprintf("[red]Couldn't fetch %s:[white] %s\n\n[yellow]press r to retry", tview.Escape(s.title), tview.Escape(describeFetchError(err))))
+		return
+	}
+
+	s.showing = false
+	close(s.stop)
+	s.layout.ResizeItem(s.box, 0, 0)
+	s.layout.ResizeItem(s.body, 0, 1)
+}
+
+// Show the spinner and what we're fetching
+func (s *splash) draw() {
+	s.view.SetText(fmt.Sprintf("%s Fetching %s…", spinnerFrames[s.frame], tview.Escape(s.title)))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json" // Needed to read and write the state file
+	"fmt"           // Needed to name the state file
+	"hash/fnv"      // Needed to give each feed its own state file
+	"io/ioutil"     // Needed to read the state file
+	"os"            // Needed to find the cache directory
+	"path/filepath" // Needed to build the state file path
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Directory under the user's cache directory where state is kept
+const STATEDIR = "earthquakecli"
+
+// Get the state file for a feed or query URL
+// Each URL gets its own file so switching feeds doesn't mix their quakes together
+func statePath(sourceURL string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(sourceURL))
+
+	return filepath.Join(cacheDir, STATEDIR, fmt.Sprintf("state-%x.json", hash.Sum64())), nil
+}
+
+// Load the quakes we'd seen the last time we ran
+// A missing or corrupt state file just means starting from scratch, so errors are ignored,
+// and quakes that don't pass the current filter are dropped since it may have changed
+func loadState(path string, filter quakeFilter) map[string]usgs.Feature {
+	quakeList := make(map[string]usgs.Feature)
+	if path == "" {
+		return quakeList
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return quakeList
+	}
+
+	var quakes []usgs.Feature
+	if err := json.Unmarshal(data, &quakes); err != nil {
+		return quakeList
+	}
+
+	for _, quake := range quakes {
+		if quake.ID == "" || !filter.shows(quake) {
+			continue
+		}
+		if quake.Properties.Status == "deleted" && !filter.showDeleted {
+			continue
+		}
+		quakeList[quake.ID] = quake
+	}
+
+	return quakeList
+}
+
+// Save the quakes we've seen so they aren't treated as new next time
+// The file is written somewhere else first and moved into place so a crash can't leave it half written
+func saveState(path string, quakeList map[string]usgs.Feature) error {
+	if path == "" {
+		return nil
+	}
+
+	quakes := make([]usgs.Feature, 0, len(quakeList))
+	for _, quake := range quakeList {
+		quakes = append(quakes, quake)
+	}
+
+	data, err := json.Marshal(quakes)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp := path + ".tmp"
+	if err := ioutil.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, path)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nested", "state.json")
+
+	noMag := testQuake("nomag", 0, 3000)
+	noMag.Properties.Mag = nil
+	tz := int64(-480)
+	withTz := testQuake("tz", 2.5, 2000)
+	withTz.Properties.Tz = &tz
+	quakeList := map[string]usgs.Feature{
+		"a":     testQuake("a", 4.1, 1000),
+		"nomag": noMag,
+		"tz":    withTz,
+	}
+
+	if err := saveState(path, quakeList); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("the temporary file was left behind: %v", err)
+	}
+
+	loaded := loadState(path, quakeFilter{})
+	if !reflect.DeepEqual(loaded, quakeList) {
+		t.Errorf("loaded %+v, want %+v", loaded, quakeList)
+	}
+}
+
+func TestLoadStateFilters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	deleted := testQuake("deleted", 5, 1000)
+	deleted.Properties.Status = "deleted"
+	saveState(path, map[string]usgs.Feature{
+		"small":   testQuake("small", 1, 1000),
+		"big":     testQuake("big", 5, 1000),
+		"deleted": deleted,
+	})
+
+	tests := []struct {
+		name   string
+		filter quakeFilter
+		want   []string
+	}{
+		{"magnitude filter changed", quakeFilter{minMagnitude: 4}, []string{"big"}},
+		{"deleted quakes shown", quakeFilter{minMagnitude: 4, showDeleted: true}, []string{"big", "deleted"}},
+	}
+
+	for _, test := range tests {
+		loaded := loadState(path, test.filter)
+		if len(loaded) != len(test.want) {
+			t.Errorf("%s: loaded %d quakes, want %v", test.name, len(loaded), test.want)
+		}
+		for _, id := range test.want {
+			if _, ok := loaded[id]; !ok {
+				t.Errorf("%s: %s wasn't loaded", test.name, id)
+			}
+		}
+	}
+}
+
+func TestLoadStateIgnoresBadFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "earthquakecli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	ioutil.WriteFile(corrupt, []byte(`[{"id":"a","properties":`), 0644)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"no state", ""},
+		{"missing", filepath.Join(dir, "missing.json")},
+		{"corrupt", corrupt},
+	}
+
+	for _, test := range tests {
+		if loaded := loadState(test.path, quakeFilter{}); loaded == nil || len(loaded) != 0 {
+			t.Errorf("%s: loaded %v, want an empty quake list", test.name, loaded)
+		}
+	}
+	if err := saveState("", map[string]usgs.Feature{"a": testQuake("a", 1, 1000)}); err != nil {
+		t.Errorf("saving with -no-state: %v", err)
+	}
+}
+
+func TestStatePathPerFeed(t *testing.T) {
+	hour, err := statePath("https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/all_hour.geojson")
+	if err != nil {
+		t.Skip("no cache directory:", err)
+	}
+	day, _ := statePath("https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/all_day.geojson")
+	again, _ := statePath("https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/all_hour.geojson")
+
+	if hour == day || hour != again {
+		t.Errorf("state paths hour %s, day %s, hour again %s, want one file per feed", hour, day, again)
+	}
+	if filepath.Base(filepath.Dir(hour)) != STATEDIR {
+		t.Errorf("state path %s isn't in %s", hour, STATEDIR)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"     // Needed to format the stats
+	"math"    // Needed to find the largest quake
+	"strings" // Needed to build the stats text and bars
+	"time"    // Needed to work out the rate
+
+	"github.com/rivo/tview"
+)
+
+// How wide the bar for the biggest band is
+const STATSBARWIDTH = 30
+
+// A range of magnitudes counted in the stats panel, from min up to but not including max
+type magnitudeBand struct {
+	label string
+	min   float64
+	max   float64
+}
+
+// The bands quakes are counted in
+var magnitudeBands = []magnitudeBand{
+	{"M<2", math.Inf(-1), 2},
+	{"M2–3.9", 2, 4},
+	{"M4–5.9", 4, 6},
+	{"M6+", 6, math.Inf(1)},
+}
+
+// Draw the stats panel for the quakes shown in the table
+func (q *quakeTable) renderStats() {
+	if q.stats == nil {
+		return
+	}
+
+	q.stats.SetText(formatStats(q.shown, q.location, q.colors))
+}
+
+// Format counts by magnitude band, the largest quake, mean depth, and how often quakes
+// are happening as an aligned block of text
+// Deleted quakes aren't counted
+func formatStats(rows []quakeRow, location *time.Location, colors colorScale) string {
+	var stats strings.Builder
+
+	counts := make([]int, len(magnitudeBands))
+	var total, unknown, depths int
+	var depthSum float64
+	var largest *quakeRow
+	largestMag := math.Inf(-1)
+	oldest := time.Now()
+	for i, row := range rows {
+		if row.quake.Properties.Status == "deleted" {
+			continue
+		}
+		total++
+
+		if t := fromMillis(row.quake.Properties.Time); t.Before(oldest) {
+			oldest = t
+		}
+		if depth, ok := quakeDepth(row.quake); ok {
+			depthSum += depth
+			depths++
+		}
+
+		mag, ok := quakeMagnitude(row.quake)
+		if !ok {
+			unknown++
+			continue
+		}
+		if mag > largestMag {
+			largestMag = mag
+			largest = &rows[i]
+		}
+		for b, band := range magnitudeBands {
+			if mag >= band.min && mag < band.max {
+				counts[b]++
+			}
+		}
+	}
+
+	most := 1
+	for _, count := range counts {
+		if count > most {
+			most = count
+		}
+	}
+
+	line := func(label, value string) {
+		fmt.Fprintf(&stats, "[yellow]%-10s[white] %s\n", label+":", value)
+	}
+
+	for b, band := range magnitudeBands {
+		bar := strings.Repeat("█", counts[b]*STATSBARWIDTH/most)
+		if bar == "" && counts[b] > 0 {
+			bar = "▏"
+		}
+		line(band.label, fmt.Sprintf("%5d [#%06x]%s[-]", counts[b], colors.color(band.min).Hex(), bar))
+	}
+	if unknown > 0 {
+		line("No mag", fmt.Sprintf("%5d", unknown))
+	}
+
+	if largest != nil {
+		line("Largest", fmt.Sprintf("[#%06x]M%.1f[-] %s, %s", colors.color(largestMag).Hex(), largestMag,
+			tview.Escape(quakePlace(largest.quake)), formatTime(largest.quake.Properties.Time, location)))
+	} else {
+		line("Largest", "—")
+	}
+
+	if depths > 0 {
+		line("Depth", fmt.Sprintf("%.1f km on average", depthSum/float64(depths)))
+	} else {
+		line("Depth", "—")
+	}
+
+	// Less than an hour of quakes would make the rate look much higher than it is
+	hours := math.Max(time.Since(oldest).Hours(), 1)
+	line("Rate", fmt.Sprintf("%.2f quakes an hour over %d quakes", float64(total)/hours, total))
+
+	return stats.String()
+}
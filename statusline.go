@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"       // Needed to cancel the fetch
+	"encoding/json" // Needed for waybar's format
+	"fmt"           // Needed for printing
+	"io"            // Needed to write the line
+	"math"          // Needed to work out the rate
+	"strings"       // Needed to shorten places
+	"time"          // Needed to work out the rate
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Formats for -status-line
+const (
+	STATUSTEXT   = "text"
+	STATUSWAYBAR = "waybar"
+)
+
+// The -status-line flag, it can be given on its own for plain text or with =waybar for JSON
+type statusLineFlag string
+
+func (s *statusLineFlag) String() string {
+	return string(*s)
+}
+
+func (s *statusLineFlag) Set(value string) error {
+	switch value {
+	case "true", STATUSTEXT:
+		*s = STATUSTEXT
+	case "false", "":
+		*s = ""
+	case STATUSWAYBAR:
+		*s = STATUSWAYBAR
+	default:
+		return fmt.Errorf("must be %s or %s", STATUSTEXT, STATUSWAYBAR)
+	}
+
+	return nil
+}
+
+// Lets -status-line be given without a value
+func (s *statusLineFlag) IsBoolFlag() bool {
+	return true
+}
+
+// Needed so -write-config quotes it
+func (s *statusLineFlag) Get() interface{} {
+	return string(*s)
+}
+
+// What waybar's custom modules read, the class can be styled in its CSS
+type waybarStatus struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class   string `json:"class"`
+}
+
+// Fetch the quakes once and print a one line summary for status bars like tmux's status-right
+// Nothing is notified, logged, or saved, so this can run every few seconds without side effects
+func runStatusLine(ctx context.Context, w io.Writer, source quakeSource, filter quakeFilter, format string, alertSig int) error {
+	data, err := source(ctx)
+	if err != nil {
+		return err
+	}
+
+	quakeList := make(map[string]usgs.Feature)
+	diffQuakes(data, quakeList, filter, quakeAlerts{})
+
+	text, tooltip, alert := formatStatusLine(quakeList, alertSig)
+	if format != STATUSWAYBAR {
+		_, err := fmt.Fprintln(w, text)
+		return err
+	}
+
+	class := "normal"
+	if alert {
+		class = "alert"
+	}
+
+	return json.NewEncoder(w).Encode(waybarStatus{Text: text, Tooltip: tooltip, Class: class})
+}
+
+// Build the status line from the largest quake and how many quakes an hour there have been,
+// eg: "M6.1 Fiji 14m ago | 37 quakes/hr", along with a longer tooltip about the largest quake
+// It's an alert if the largest quake has a PAGER alert, a tsunami flag, or is significant enough
+func formatStatusLine(quakeList map[string]usgs.Feature, alertSig int) (string, string, bool) {
+	var largest *usgs.Feature
+	largestMag := math.Inf(-1)
+	count := 0
+	oldest := time.Now()
+	for id := range quakeList {
+		quake := quakeList[id]
+		if quake.Properties.Status == "deleted" {
+			continue
+		}
+		count++
+
+		if t := fromMillis(quake.Properties.Time); t.Before(oldest) {
+			oldest = t
+		}
+
+		mag, ok := quakeMagnitude(quake)
+		if ok && (mag > largestMag || (mag == largestMag && quake.Properties.Time > largest.Properties.Time)) {
+			largestMag = mag
+			largest = &quake
+		}
+	}
+
+	// Less than an hour of quakes would make the rate look much higher than it is
+	// Quiet feeds get a decimal place so they don't all show 0
+	rate := float64(count) / math.Max(time.Since(oldest).Hours(), 1)
+	rateText := fmt.Sprintf("%.0f quakes/hr", rate)
+	if rate < 10 {
+		rateText = fmt.Sprintf("%.1f quakes/hr", rate)
+	}
+	if largest == nil {
+		return "No quakes | " + rateText, "", false
+	}
+
+	age := formatRelative(time.Since(fromMillis(largest.Properties.Time)))
+	text := fmt.Sprintf("M%.1f %s %s | %s", largestMag, shortPlace(quakePlace(*largest)), age, rateText)
+	tooltip := fmt.Sprintf("M%s %s, %s", formatMagnitude(*largest), quakePlace(*largest), age)
+
+	_, pager := alertColors[largest.Properties.Alert]
+	alert := (pager && largest.Properties.Alert != "green") || largest.Properties.Tsunami == 1 ||
+		(alertSig > 0 && largest.Properties.Sig >= alertSig)
+
+	return text, tooltip, alert
+}
+
+// Shorten a USGS place to the region at the end, eg: "105 km SE of Lambasa, Fiji" to "Fiji"
+func shortPlace(place string) string {
+	if comma := strings.LastIndex(place, ","); comma >= 0 {
+		return strings.TrimSpace(place[comma+1:])
+	}
+
+	return place
+}
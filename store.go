@@ -0,0 +1,84 @@
+package main
+
+import (
+	"sort" // Needed to put the quakes in order
+	"sync" // Needed to share the quakes between goroutines
+	"time" // Needed to remember when the quakes were last fetched
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// The quakes we're tracking, for anything that reads them from another goroutine, eg: the API handlers
+// The quake list itself belongs to the goroutine that fetches, only it may diff into it, so after each
+// fetch it publishes a copy here, readers only ever get copies so they can't see half a fetch
+// The TUI doesn't need this, the table's eventStore belongs to the tview event loop and the fetching
+// goroutine only hands it copies of rows through QueueUpdateDraw, so the detail pane, exports and
+// everything else that runs on the event loop can read it without a lock
+type quakeStore struct {
+	mu          sync.RWMutex
+	quakes      map[string]usgs.Feature
+	lastUpdated time.Time
+	err         error // From the last fetch, nil if it worked
+}
+
+// A copy of the store at one moment, safe to use without holding the lock
+type storeSnapshot struct {
+	quakes      []usgs.Feature // Newest first
+	lastUpdated time.Time      // Zero if no fetch has worked yet
+	err         error
+}
+
+// Replace the stored quakes with a copy of the quake list after a fetch
+// A failed fetch keeps the quakes we had and only records the error
+func (s *quakeStore) publish(quakeList map[string]usgs.Feature, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == usgs.ErrNotModified {
+		err = nil
+	}
+	s.err = err
+	if err != nil {
+		return
+	}
+
+	s.quakes = make(map[string]usgs.Feature, len(quakeList))
+	for id, quake := range quakeList {
+		s.quakes[id] = quake
+	}
+	s.lastUpdated = time.Now()
+}
+
+// Get a copy of every quake, newest first, along with how the last fetch went
+func (s *quakeStore) snapshot() storeSnapshot {
+	s.mu.RLock()
+	snapshot := storeSnapshot{
+		quakes:      make([]usgs.Feature, 0, len(s.quakes)),
+		lastUpdated: s.lastUpdated,
+		err:         s.err,
+	}
+	for _, quake := range s.quakes {
+		snapshot.quakes = append(snapshot.quakes, quake)
+	}
+	s.mu.RUnlock()
+
+	// Sorted outside the lock so a slow reader doesn't hold up the next publish
+	sort.Slice(snapshot.quakes, func(i, j int) bool {
+		a, b := snapshot.quakes[i].Properties, snapshot.quakes[j].Properties
+		if a.Time != b.Time {
+			return a.Time > b.Time
+		}
+		return snapshot.quakes[i].ID < snapshot.quakes[j].ID
+	})
+
+	return snapshot
+}
+
+// Get one quake by ID
+func (s *quakeStore) get(id string) (usgs.Feature, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quake, ok := s.quakes[id]
+	return quake, ok
+}
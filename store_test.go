@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Publish fetch after fetch while readers take snapshots, run with -race to check the locking
+// Every quake in a fetch is updated at the same time, so a snapshot mixing fetches saw half of one
+func TestQuakeStoreConcurrentPublish(t *testing.T) {
+	const fetches, quakes, readers = 200, 50, 4
+
+	store := &quakeStore{}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+
+		// The quake list is reused between fetches like the update goroutine does
+		quakeList := make(map[string]usgs.Feature)
+		for fetch := 1; fetch <= fetches; fetch++ {
+			for i := 0; i < quakes; i++ {
+				quake := testQuake(fmt.Sprintf("q%02d", i), 3, int64(i)*1000)
+				quake.Properties.Updated = int64(fetch)
+				quakeList[quake.ID] = quake
+			}
+			store.publish(quakeList, nil)
+		}
+	}()
+
+	errs := make(chan error, readers)
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				snapshot := store.snapshot()
+				for i, quake := range snapshot.quakes {
+					if quake.Properties.Updated != snapshot.quakes[0].Properties.Updated {
+						errs <- fmt.Errorf("snapshot mixes fetches %d and %d", snapshot.quakes[0].Properties.Updated, quake.Properties.Updated)
+						return
+					}
+					if i > 0 && quake.Properties.Time > snapshot.quakes[i-1].Properties.Time {
+						errs <- errors.New("snapshot isn't newest first")
+						return
+					}
+				}
+				store.get("q07")
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if snapshot := store.snapshot(); len(snapshot.quakes) != quakes || snapshot.quakes[0].Properties.Updated != fetches {
+		t.Errorf("last snapshot has %d quakes from fetch %d, want %d from fetch %d", len(snapshot.quakes), snapshot.quakes[0].Properties.Updated, quakes, fetches)
+	}
+}
+
+func TestQuakeStoreFailedFetchKeepsQuakes(t *testing.T) {
+	store := &quakeStore{}
+	quakeList := map[string]usgs.Feature{"a": testQuake("a", 3, 1000)}
+	store.publish(quakeList, nil)
+
+	failed := errors.New("connection refused")
+	store.publish(quakeList, failed)
+	snapshot := store.snapshot()
+	if len(snapshot.quakes) != 1 || snapshot.err != failed {
+		t.Errorf("after a failed fetch: %d quakes, err %v, want 1 quake and the error", len(snapshot.quakes), snapshot.err)
+	}
+
+	store.publish(quakeList, usgs.ErrNotModified)
+	if snapshot := store.snapshot(); len(snapshot.quakes) != 1 || snapshot.err != nil {
+		t.Errorf("after not modified: %d quakes, err %v, want 1 quake and no error", len(snapshot.quakes), snapshot.err)
+	}
+	if _, ok := store.get("a"); !ok {
+		t.Error("get(a) didn't find it")
+	}
+}
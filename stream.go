@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"       // Needed to cancel fetches on shutdown
+	"encoding/json" // Needed to write each change
+	"fmt"           // Needed to report fetch errors
+	"io"            // Needed to write the stream
+	"os"            // Needed to report fetch errors
+	"time"          // Needed to follow the feed
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// A change to a quake as it's written to the stream, one JSON object a line
+type streamEvent struct {
+	Action   string `json:"action"`
+	Replaces string `json:"replaces,omitempty"` // The ID the quake had before USGS associated it with this one
+	loggedEvent
+}
+
+// Write every quake as a JSON line, then keep writing a line for each one that's new, updated, or
+// removed until ctx is cancelled, eg: for piping into jq
+// Each line goes out in one write, so stopping part way never leaves half a line behind
+func runStream(ctx context.Context, w io.Writer, source quakeSource, filter quakeFilter, alerts quakeAlerts, refresh time.Duration) error {
+	quakeList := make(map[string]usgs.Feature)
+	encoder := json.NewEncoder(w)
+
+	// Everything already in the feed is new the first time round
+	if err := streamChanges(ctx, encoder, quakeList, source, filter, alerts); err != nil {
+		return err
+	}
+
+	updateTicker := time.NewTicker(refresh)
+	defer updateTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-updateTicker.C:
+			// Keep streaming if a later fetch fails, it'll probably work next time
+			err := streamChanges(ctx, encoder, quakeList, source, filter, alerts)
+			if err != nil && err != usgs.ErrNotModified && ctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, "fetch failed:", describeFetchError(err))
+			}
+		}
+	}
+}
+
+// Fetch the quakes and write a line for each change, oldest first
+func streamChanges(ctx context.Context, encoder *json.Encoder, quakeList map[string]usgs.Feature, source quakeSource, filter quakeFilter, alerts quakeAlerts) error {
+	data, err := source(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes, _ := diffQuakes(data, quakeList, filter, alerts)
+	alerts.metrics.track(quakeList)
+
+	for _, change := range changes {
+		event := streamEvent{
+			Action:   changeActions[change.action],
+			Replaces: change.replaces,
+			loggedEvent: loggedEvent{
+				exportedQuake: exportQuake(change.quake),
+				Updated:       fromMillis(change.quake.Properties.Updated).UTC().Format(time.RFC3339),
+			},
+		}
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
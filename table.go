@@ -0,0 +1,1050 @@
+package main
+
+import (
+	"fmt"     // Needed to format relative times
+	"math"    // Needed to sort quakes without a depth or magnitude
+	"sort"    // Needed to sort the quakes
+	"strings" // Needed to join the summary
+	"time"    // Needed to work out relative times
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+// Markers for new, updated, and newly reviewed rows
+const (
+	NEWMARKER      = "● "
+	UPDATEDMARKER  = "○ "
+	REVIEWEDMARKER = "✓ "
+)
+
+// Narrowest we'll cut the place column down to, any narrower and it's not worth reading
+const MINPLACEWIDTH = 12
+
+// Columns the table can be sorted by
+const (
+	sortTime = iota
+	sortMagnitude
+	sortDepth
+	sortDistance
+	sortFelt
+	sortColumns
+)
+
+// Table columns for each of the sortable columns
+var sortColumn = map[int]int{
+	sortTime:      columnTime,
+	sortMagnitude: columnMagnitude,
+	sortDepth:     columnDepth,
+	sortDistance:  columnDistance,
+	sortFelt:      columnFelt,
+}
+
+// Colors for the PAGER alert levels
+var alertColors = map[string]tcell.Color{
+	"green":  tcell.ColorGreen,
+	"yellow": tcell.ColorYellow,
+	"orange": tcell.ColorOrange,
+	"red":    tcell.ColorRed,
+}
+
+// A quake and the text for each of its cells
+// The time and distance cells depend on display settings so the table fills those in
+type quakeRow struct {
+	quake     usgs.Feature
+	cells     []string
+	firstSeen time.Time           // When the quake arrived, zero if it was already there when we started
+	revised   time.Time           // When the quake was last updated, zero if it hasn't been
+	reviewed  time.Time           // When the quake went from automatic to reviewed, zero if we didn't see it happen
+	revisions []magnitudeRevision // Magnitude changes we've seen, oldest first
+	region    string              // The watch region the quake is in, pinned to the top if it's set
+	replaces  string              // The ID the quake was shown under before USGS associated it with this one
+}
+
+// A change to a quake's magnitude
+type magnitudeRevision struct {
+	from float64
+	to   float64
+	at   time.Time
+}
+
+// The quakes in the table, rendered from the event store so the table can be rebuilt at any time
+// This should only be used from the tview event loop, eg: inside QueueUpdateDraw
+type quakeTable struct {
+	table         *tview.Table
+	events        eventStore
+	shown         []quakeRow // The rows that pass the filter, as they appear in the table
+	filter        func(usgs.Feature) bool
+	filterText    string  // What was typed for the filter, to show in the footer
+	filtered      int     // How many quakes the filter is hiding
+	minMag        float64 // Quakes below this are hidden, changed with '+' and '-'
+	fetchMinMag   float64 // -min-magnitude, '-' can't go below it since nothing under it is fetched
+	belowMinMag   int     // How many quakes minMag is hiding
+	columns       []int   // The columns shown, in order
+	wideColumns   []int   // The columns picked with -columns, shown in the wide layout
+	layout        int     // One of the layout constants
+	narrow        bool    // Showing the narrow layout
+	sortBy        int
+	ascending     bool
+	headerSorted  bool // The last click sorted by a header, so a double click there doesn't sort it back
+	relativeTime  bool
+	location      *time.Location // Time zone for absolute times
+	colors        colorScale
+	colorBy       int       // How the quakes are colored, one of the colorBy constants
+	home          *geoPoint // Distances are measured from here, if it's set
+	miles         bool
+	dms           bool            // Coordinates are in degrees, minutes and seconds instead of decimal
+	nearHighlight float64         // Quakes within this many km of home are bold, 0 turns it off
+	summary       *tview.TextView // Shows what's in the feed, if it's set
+	banner        *banner         // Headlines for big new quakes, if it's set
+	stats         *tview.TextView // Shows counts by magnitude and other stats, if it's set
+	cards         *tview.List     // Shows the quakes as cards instead of the table, if it's set
+	syncingCards  bool            // Set while the cards are redrawn so their selection doesn't move the table's
+	metadata      usgs.Metadata   // From the last time the feed changed
+	highlight     time.Duration   // How long new and updated quakes stand out for, 0 turns it off
+	nextFade      time.Time       // When the next highlight runs out or row starts to age out, zero if there's none
+	width         int             // Width the places were last fitted to, 0 if they need fitting again
+	revisionNote  time.Duration   // How long magnitude changes are shown next to the magnitude, 0 turns it off
+	insecure      bool            // Certificates aren't being checked, which the summary bar warns about
+	maxAge        time.Duration   // How long quakes stay in the table, 0 keeps them forever
+	feedSkew      time.Duration   // How far the feed's clock was ahead of ours when the last feed came in
+	screenWidth   int             // How wide the terminal was at the last draw, for fitting the summary bar
+
+	// Quakes hidden with 'x', and each batch that was dismissed so they can be undone in order
+	dismissed       map[string]bool
+	dismissals      [][]string
+	revealDismissed bool // Show the dismissed quakes greyed out instead of hiding them
+
+	// Aftershock grouping, groups and members are worked out again every render
+	grouping    bool
+	groupRadius float64 // km
+	groupWindow time.Duration
+	groups      map[string]*quakeGroup // By mainshock ID
+	members     map[string]string      // Mainshock ID by aftershock ID
+	expanded    map[string]bool        // Mainshocks showing their aftershocks
+
+	// While paused, changes are held here instead of being applied, merged so each quake only
+	// has its latest change
+	paused          bool
+	pendingRows     map[string]quakeRow
+	pendingRemoved  map[string]bool
+	pendingMetadata *usgs.Metadata
+}
+
+// Create a new quake table showing the given columns, sorted by time, newest first
+func newQuakeTable(table *tview.Table, columns []int, colors colorScale, home *geoPoint, miles bool) *quakeTable {
+	quakes := &quakeTable{
+		table:       table,
+		columns:     columns,
+		wideColumns: columns,
+		sortBy:      sortTime,
+		location:    time.Local,
+		colors:      colors,
+		home:        home,
+		miles:       miles,
+	}
+	quakes.renderHeader()
+
+	return quakes
+}
+
+// Apply a batch of new or updated quakes and removals to the table in one go
+// The changes go into the event store and then the table is redrawn from it, and the selection
+// stays on the same quake if it's still there
+// While paused the changes are held until we resume
+func (q *quakeTable) apply(batch []quakeRow, removed []string) {
+	if q.paused {
+		q.hold(batch, removed)
+		return
+	}
+
+	selectedID := q.selectedID()
+
+	// Remember when each quake arrived or changed so it can be highlighted
+	now := time.Now()
+	upserted := make(map[string]bool, len(batch))
+	for _, row := range batch {
+		upserted[row.quake.ID] = true
+		before, ok := q.events.get(row.quake.ID)
+
+		// A quake that's taken over from a duplicate keeps its row's history, selection and dismissal
+		if row.replaces != "" {
+			if replaced, had := q.events.remove(row.replaces); had {
+				before, ok = replaced, true
+				if selectedID == row.replaces {
+					selectedID = row.quake.ID
+				}
+				if q.dismissed[row.replaces] {
+					q.dismissed[row.quake.ID] = true
+				}
+			}
+		}
+
+		if ok {
+			row.firstSeen = before.firstSeen
+			row.revised = now
+			row.reviewed = before.reviewed
+			row.revisions = before.revisions
+			oldMag, hadMag := quakeMagnitude(before.quake)
+			newMag, hasMag := quakeMagnitude(row.quake)
+			if hadMag && hasMag && oldMag != newMag {
+				// Copy so the previous row's history isn't shared
+				row.revisions = append(append([]magnitudeRevision{}, before.revisions...), magnitudeRevision{from: oldMag, to: newMag, at: now})
+			}
+			if before.quake.Properties.Status == "automatic" && row.quake.Properties.Status == "reviewed" {
+				row.reviewed = now
+			}
+		} else {
+			row.firstSeen = now
+		}
+
+		q.events.upsert(row)
+	}
+	for _, id := range removed {
+		if !upserted[id] {
+			q.events.remove(id)
+		}
+	}
+
+	q.render()
+	q.selectID(selectedID)
+}
+
+// Fill the table with quakes we already knew about, eg: from the last run
+// Unlike apply, these aren't highlighted as new
+func (q *quakeTable) restore(rows []quakeRow) {
+	for _, row := range rows {
+		q.events.upsert(row)
+	}
+	q.resort()
+}
+
+// Hold changes while paused, a later change to a quake replaces an earlier one
+func (q *quakeTable) hold(batch []quakeRow, removed []string) {
+	for _, id := range removed {
+		delete(q.pendingRows, id)
+		q.pendingRemoved[id] = true
+	}
+	for _, row := range batch {
+		delete(q.pendingRemoved, row.quake.ID)
+		q.pendingRows[row.quake.ID] = row
+		if row.replaces != "" {
+			delete(q.pendingRows, row.replaces)
+		}
+	}
+
+	q.renderSummary()
+}
+
+// Update the feed metadata, held until we resume if we're paused so the summary matches the table
+// The feed's clock is noted straight away though, since quakes age out by it even while we're paused
+func (q *quakeTable) setMetadata(metadata usgs.Metadata) {
+	if metadata.Generated != 0 {
+		q.feedSkew = fromMillis(metadata.Generated).Sub(time.Now())
+	}
+	if q.paused {
+		q.pendingMetadata = &metadata
+		return
+	}
+
+	q.metadata = metadata
+}
+
+// Pause or resume applying updates, resuming applies everything that was held in one go
+func (q *quakeTable) togglePause() {
+	if !q.paused {
+		q.paused = true
+		q.pendingRows = make(map[string]quakeRow)
+		q.pendingRemoved = make(map[string]bool)
+		q.renderSummary()
+		return
+	}
+
+	q.paused = false
+	if q.pendingMetadata != nil {
+		q.metadata = *q.pendingMetadata
+	}
+
+	batch := make([]quakeRow, 0, len(q.pendingRows))
+	for _, row := range q.pendingRows {
+		batch = append(batch, row)
+	}
+	removed := make([]string, 0, len(q.pendingRemoved))
+	for id := range q.pendingRemoved {
+		removed = append(removed, id)
+	}
+	q.pendingRows, q.pendingRemoved, q.pendingMetadata = nil, nil, nil
+
+	q.apply(batch, removed)
+}
+
+// Only show quakes that match the filter typed after '/', or all of them if match is nil
+// The hidden quakes are still tracked so clearing the filter brings them straight back
+func (q *quakeTable) setFilter(text string, match func(usgs.Feature) bool) {
+	selectedID := q.selectedID()
+	q.filter, q.filterText = match, text
+	q.render()
+	q.selectID(selectedID)
+}
+
+// Get the ID of the selected quake, if there is one
+func (q *quakeTable) selectedID() string {
+	selected, _ := q.table.GetSelection()
+	if selected > 0 && selected <= len(q.shown) {
+		return q.shown[selected-1].quake.ID
+	}
+
+	return ""
+}
+
+// Select the row for a quake
+// If it isn't in the table any more the selection is kept inside the table
+func (q *quakeTable) selectID(id string) {
+	for i, row := range q.shown {
+		if row.quake.ID == id {
+			q.table.Select(i+1, 0)
+			q.selectCard()
+			return
+		}
+	}
+
+	if selected, _ := q.table.GetSelection(); selected > len(q.shown) && len(q.shown) > 0 {
+		q.table.Select(len(q.shown), 0)
+	}
+	q.selectCard()
+}
+
+// Turn aftershock grouping on or off, if an aftershock was selected its mainshock is selected instead
+func (q *quakeTable) toggleGrouping() {
+	selectedID := q.selectedID()
+	if mainshock, ok := q.members[selectedID]; ok {
+		selectedID = mainshock
+	}
+
+	q.grouping = !q.grouping
+	q.render()
+	q.selectID(selectedID)
+}
+
+// Expand or collapse the selected mainshock's aftershocks
+// Returns false if the selected quake doesn't have any grouped under it
+func (q *quakeTable) toggleGroup() bool {
+	selectedID := q.selectedID()
+	if q.groups[selectedID] == nil {
+		return false
+	}
+
+	if q.expanded == nil {
+		q.expanded = make(map[string]bool)
+	}
+	q.expanded[selectedID] = !q.expanded[selectedID]
+	q.render()
+	q.selectID(selectedID)
+
+	return true
+}
+
+// Mark a mainshock's place with how many aftershocks it has, and indent its aftershocks
+func (q *quakeTable) groupText(row quakeRow, text string) string {
+	if group := q.groups[row.quake.ID]; group != nil {
+		arrow := "▶ "
+		if q.expanded[row.quake.ID] {
+			arrow = "▼ "
+		}
+		return arrow + text + group.summary()
+	}
+	if _, ok := q.members[row.quake.ID]; ok {
+		return "  └ " + text
+	}
+
+	return text
+}
+
+// Move the selection to the first or last quake in the table
+// The selection then follows the quake, not the row, as updates come in
+func (q *quakeTable) jumpTo(last bool) {
+	if len(q.shown) == 0 {
+		return
+	}
+
+	row := q.shown[0]
+	if last {
+		row = q.shown[len(q.shown)-1]
+	}
+	q.selectID(row.quake.ID)
+}
+
+// Move the selection to the largest quake in the table, the newest one if there's a tie
+func (q *quakeTable) jumpToLargest() {
+	var largest *quakeRow
+	for i, row := range q.shown {
+		mag, ok := quakeMagnitude(row.quake)
+		if !ok {
+			continue
+		}
+		if largest == nil || mag > sortableMagnitude(largest.quake) ||
+			(mag == sortableMagnitude(largest.quake) && row.quake.Properties.Time > largest.quake.Properties.Time) {
+			largest = &q.shown[i]
+		}
+	}
+
+	if largest != nil {
+		q.selectID(largest.quake.ID)
+	}
+}
+
+// Redraw the whole table from the quakes that pass the filter and the minimum magnitude
+// Quakes in watch regions are pinned above the rest whatever the filter, and stay put when scrolling
+// Dismissed quakes are left out unless they're being revealed
+func (q *quakeTable) render() {
+	rows := q.sorted()
+	q.shown = q.shown[:0]
+	for _, row := range rows {
+		if row.region != "" && (!q.dismissed[row.quake.ID] || q.revealDismissed) {
+			q.shown = append(q.shown, row)
+		}
+	}
+	q.table.SetFixed(len(q.shown)+1, 0)
+
+	var visible []quakeRow
+	q.filtered, q.belowMinMag = 0, 0
+	for _, row := range rows {
+		switch {
+		case row.region != "":
+		case q.dismissed[row.quake.ID] && !q.revealDismissed:
+		case !q.aboveMinMag(row.quake):
+			q.belowMinMag++
+		case q.filter == nil || q.filter(row.quake):
+			visible = append(visible, row)
+		default:
+			q.filtered++
+		}
+	}
+
+	// Aftershocks are hidden under their mainshock unless it's been expanded
+	q.members, q.groups = nil, nil
+	if q.grouping {
+		q.members, q.groups = clusterAftershocks(visible, q.groupRadius, q.groupWindow)
+	}
+	for _, row := range visible {
+		if _, ok := q.members[row.quake.ID]; ok {
+			continue
+		}
+		q.shown = append(q.shown, row)
+		if q.groups[row.quake.ID] == nil || !q.expanded[row.quake.ID] {
+			continue
+		}
+		for _, member := range visible {
+			if q.members[member.quake.ID] == row.quake.ID {
+				q.shown = append(q.shown, member)
+			}
+		}
+	}
+
+	q.renderHeader()
+	q.nextFade = time.Time{}
+	q.width = 0
+	for i, row := range q.shown {
+		q.renderRow(i+1, row)
+	}
+
+	// Get rid of any rows left over from quakes that were removed or filtered out
+	for q.table.GetRowCount() > len(q.shown)+1 {
+		q.table.RemoveRow(q.table.GetRowCount() - 1)
+	}
+
+	q.renderSummary()
+	q.renderStats()
+	q.renderCards()
+}
+
+// Draw the summary bar with the feed title, how many quakes are shown, the biggest one, and when
+// the feed was generated
+// Filtered out quakes aren't in the table at all, so they're counted from the feed
+func (q *quakeTable) renderSummary() {
+	if q.summary == nil {
+		return
+	}
+
+	parts := []string{}
+	if q.insecure {
+		parts = append(parts, "[white:red:b] INSECURE — TLS certificates aren't checked [-:-:-]")
+	}
+	if q.paused {
+		parts = append(parts, fmt.Sprintf("[black:yellow] PAUSED — %d pending updates [-:-]", len(q.pendingRows)+len(q.pendingRemoved)))
+	}
+	if q.metadata.Title != "" {
+		parts = append(parts, "[::b]"+tview.Escape(q.metadata.Title)+"[::-]")
+	}
+
+	if len(q.shown) < q.metadata.Count {
+		parts = append(parts, fmt.Sprintf("Showing %d of %d events", len(q.shown), q.metadata.Count))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d events", len(q.shown)))
+	}
+
+	largest := math.Inf(-1)
+	for _, row := range q.shown {
+		if mag, ok := quakeMagnitude(row.quake); ok && mag > largest {
+			largest = mag
+		}
+	}
+	if !math.IsInf(largest, -1) {
+		parts = append(parts, fmt.Sprintf("Largest [#%06x]M%.1f[-]", q.colors.color(largest).Hex(), largest))
+	}
+
+	if q.metadata.Generated != 0 {
+		parts = append(parts, "Generated "+formatTime(q.metadata.Generated, q.location))
+	}
+
+	// The sparkline gets whatever room is left on the line
+	text := strings.Join(parts, " · ")
+	label := " per 10m"
+	if spark := q.sparkline(q.screenWidth - tview.TaggedStringWidth(text+" · "+label)); spark != "" {
+		text += " · " + spark + label
+	}
+
+	q.summary.SetText(text)
+}
+
+// Fit the summary bar to the terminal, this is called before every draw but only redraws the
+// summary when the width changes
+func (q *quakeTable) fitSummary(screenWidth int) {
+	if screenWidth == q.screenWidth {
+		return
+	}
+
+	q.screenWidth = screenWidth
+	q.renderSummary()
+}
+
+// Move on to sorting by the next column
+// Distance is skipped when there's no home to measure from, and felt when it isn't shown
+func (q *quakeTable) cycleSort() {
+	q.sortBy = (q.sortBy + 1) % sortColumns
+	if q.sortBy == sortDistance && q.home == nil {
+		q.sortBy = (q.sortBy + 1) % sortColumns
+	}
+	if q.sortBy == sortFelt && !q.showing(columnFelt) {
+		q.sortBy = (q.sortBy + 1) % sortColumns
+	}
+	q.resort()
+}
+
+// Sort by the column at position in the table, or flip the direction if we're already sorting by it
+// Columns that can't be sorted by are ignored
+func (q *quakeTable) sortByColumn(position int) {
+	if position < 0 || position >= len(q.columns) {
+		return
+	}
+
+	for sortBy, column := range sortColumn {
+		if column != q.columns[position] {
+			continue
+		}
+		if sortBy == q.sortBy {
+			q.ascending = !q.ascending
+		} else {
+			q.sortBy = sortBy
+		}
+		q.resort()
+		return
+	}
+}
+
+// Check if the table can be sorted by a column
+func (q *quakeTable) sortable(column int) bool {
+	for sortBy, sorted := range sortColumn {
+		if sorted == column {
+			return sortBy != sortDistance || q.home != nil
+		}
+	}
+
+	return false
+}
+
+// Check if a column is shown in the table
+func (q *quakeTable) showing(column int) bool {
+	for _, shown := range q.columns {
+		if shown == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Flip the sort direction
+func (q *quakeTable) flipSort() {
+	q.ascending = !q.ascending
+	q.resort()
+}
+
+// Rebuild the table in the current sort order, keeping the selection on the same quake
+func (q *quakeTable) resort() {
+	selectedID := q.selectedID()
+	q.render()
+	q.selectID(selectedID)
+}
+
+// Get every quake in the store, sorted however the table is sorted
+func (q *quakeTable) sorted() []quakeRow {
+	rows := q.events.snapshot()
+	sort.SliceStable(rows, func(i, j int) bool {
+		return q.less(rows[i], rows[j])
+	})
+
+	return rows
+}
+
+// Switch between absolute and relative times
+func (q *quakeTable) toggleRelativeTime() {
+	q.relativeTime = !q.relativeTime
+	q.refreshTimes()
+	q.width = 0
+}
+
+// Get the color for a quake in the current color mode
+func (q *quakeTable) quakeColor(quake usgs.Feature) tcell.Color {
+	return colorModes[q.colorBy].color(q.colors, quake)
+}
+
+// Get the legend for the current color mode
+func (q *quakeTable) legend() string {
+	return colorModes[q.colorBy].legend(q.colors)
+}
+
+// Switch to the next way of coloring the quakes
+func (q *quakeTable) cycleColors() {
+	q.colorBy = (q.colorBy + 1) % len(colorModes)
+	q.render()
+}
+
+// Cut long places down so the table fits in width columns, this is called whenever the table
+// is drawn but only does anything if the width or the rows changed
+// tview adds the ellipsis without splitting characters, and the detail pane has the whole place
+func (q *quakeTable) fitPlaces(width int) {
+	if width == q.width {
+		return
+	}
+	q.width = width
+
+	// Everything but the place column keeps its full width, along with the borders around each column
+	// or the space between them in the narrow layout
+	place := -1
+	used := len(q.columns) + 1
+	if q.narrow {
+		used = len(q.columns)
+	}
+	for position, column := range q.columns {
+		if column == columnLocation {
+			place = position
+			continue
+		}
+
+		widest := 0
+		for row := 0; row < q.table.GetRowCount(); row++ {
+			if cellWidth := tview.TaggedStringWidth(q.table.GetCell(row, position).Text); cellWidth > widest {
+				widest = cellWidth
+			}
+		}
+		used += widest
+	}
+	if place < 0 {
+		return
+	}
+
+	maxWidth := width - used
+	if maxWidth < MINPLACEWIDTH {
+		maxWidth = MINPLACEWIDTH
+	}
+	for row := 1; row < q.table.GetRowCount(); row++ {
+		q.table.GetCell(row, place).MaxWidth = maxWidth
+	}
+}
+
+// Redraw the rows once a highlight has run out, this is called every draw tick
+func (q *quakeTable) refreshHighlights() {
+	if !q.nextFade.IsZero() && time.Now().After(q.nextFade) {
+		q.render()
+	}
+}
+
+// Check if a row was new or updated recently enough to be highlighted
+func (q *quakeTable) highlighted(since time.Time) bool {
+	return q.recent(since, q.highlight)
+}
+
+// Check if something happened less than lasts ago, so it should still stand out
+// Also keeps track of when the next one runs out so we know when to redraw
+func (q *quakeTable) recent(since time.Time, lasts time.Duration) bool {
+	if lasts <= 0 || since.IsZero() {
+		return false
+	}
+
+	fade := since.Add(lasts)
+	if !time.Now().Before(fade) {
+		return false
+	}
+	if q.nextFade.IsZero() || fade.Before(q.nextFade) {
+		q.nextFade = fade
+	}
+
+	return true
+}
+
+// Get the note for a magnitude that changed recently, eg: " (↑ from 6.50)"
+func (q *quakeTable) revisionText(row quakeRow) string {
+	if len(row.revisions) == 0 {
+		return ""
+	}
+
+	last := row.revisions[len(row.revisions)-1]
+	if !q.recent(last.at, q.revisionNote) {
+		return ""
+	}
+
+	arrow := "↑"
+	if last.to < last.from {
+		arrow = "↓"
+	}
+
+	return fmt.Sprintf(" (%s from %.02f)", arrow, last.from)
+}
+
+// Get the magnitude changes we've seen for a quake, oldest first
+func (q *quakeTable) revisionsFor(id string) []magnitudeRevision {
+	row, _ := q.events.get(id)
+
+	return row.revisions
+}
+
+// Get the marker for the start of a row, if it's new or was updated recently
+func (q *quakeTable) marker(row quakeRow) string {
+	switch {
+	case q.highlighted(row.reviewed):
+		return REVIEWEDMARKER
+	case q.highlighted(row.revised):
+		return UPDATEDMARKER
+	case q.highlighted(row.firstSeen):
+		return NEWMARKER
+	}
+
+	return ""
+}
+
+// Redraw the time columns, relative times go stale so this is called every draw tick
+func (q *quakeTable) refreshTimes() {
+	for position, column := range q.columns {
+		if column != columnTime && column != columnUpdated {
+			continue
+		}
+		for i, row := range q.shown {
+			text := q.timeText(row)
+			if column == columnUpdated {
+				text = updatedText(row.quake)
+			}
+			if position == 0 {
+				text = q.marker(row) + text
+			}
+			q.table.GetCell(i+1, position).Text = text
+		}
+	}
+}
+
+// Get the text for a quake's time cell
+func (q *quakeTable) timeText(row quakeRow) string {
+	if q.relativeTime {
+		return formatRelative(time.Since(fromMillis(row.quake.Properties.Time)))
+	}
+
+	return formatTime(row.quake.Properties.Time, q.location)
+}
+
+// Get the text for a quake's updated cell, how long ago USGS last revised its solution
+// A solution revised minutes ago is still settling, one left alone for hours probably won't change much
+func updatedText(quake usgs.Feature) string {
+	if quake.Properties.Updated == 0 {
+		return "-"
+	}
+
+	return formatRelative(time.Since(fromMillis(quake.Properties.Updated)))
+}
+
+// Get the text for a quake's coordinates cell, in decimal or DMS depending on -coord-format
+func (q *quakeTable) coordinatesText(row quakeRow) string {
+	point, ok := quakePoint(row.quake)
+	if !ok {
+		return "-"
+	}
+	if q.dms {
+		return formatDMS(point)
+	}
+
+	return formatDecimal(point)
+}
+
+// Get the distance from home to a quake in km, if it has coordinates
+func (q *quakeTable) distance(row quakeRow) (float64, bool) {
+	point, ok := quakePoint(row.quake)
+	if !ok || q.home == nil {
+		return 0, false
+	}
+
+	return haversine(*q.home, point), true
+}
+
+// Get the text for a quake's distance cell, in km or miles and which way it is from home,
+// eg: 312 km NE
+func (q *quakeTable) distanceText(row quakeRow) string {
+	distance, ok := q.distance(row)
+	if !ok {
+		return "-"
+	}
+
+	text := fmt.Sprintf("%.0f km", distance)
+	if q.miles {
+		text = fmt.Sprintf("%.0f mi", distance/KMPERMILE)
+	}
+
+	// Right on top of home there's no direction worth giving
+	if distance < 1 {
+		return text
+	}
+	point, _ := quakePoint(row.quake)
+	return text + " " + compassPoint(bearing(*q.home, point))
+}
+
+// Check if a quake is close enough to home to stand out, see -near-me-highlight
+func (q *quakeTable) nearHome(row quakeRow) bool {
+	distance, ok := q.distance(row)
+
+	return ok && q.nearHighlight > 0 && distance <= q.nearHighlight
+}
+
+// Format how long ago something happened, eg: "1h 12m ago"
+func formatRelative(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm ago", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+
+	return fmt.Sprintf("%dd %dh ago", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+}
+
+// Check if quake a belongs above quake b in the current sort order
+// Times are compared using the raw USGS timestamps, never the formatted text, since
+// the display format has no year and its zone abbreviation doesn't parse back reliably
+// Ties are broken by time, newest first, then by ID so the order is always the same
+func (q *quakeTable) less(a, b quakeRow) bool {
+	var x, y float64
+	switch q.sortBy {
+	case sortMagnitude:
+		x, y = sortableMagnitude(a.quake), sortableMagnitude(b.quake)
+	case sortDepth:
+		x, y = sortableDepth(a.quake), sortableDepth(b.quake)
+	case sortDistance:
+		x, y = q.sortableDistance(a), q.sortableDistance(b)
+	case sortFelt:
+		x, y = float64(a.quake.Properties.Felt), float64(b.quake.Properties.Felt)
+	default:
+		if a.quake.Properties.Time != b.quake.Properties.Time {
+			if q.ascending {
+				return a.quake.Properties.Time < b.quake.Properties.Time
+			}
+			return a.quake.Properties.Time > b.quake.Properties.Time
+		}
+	}
+
+	if x != y {
+		if q.ascending {
+			return x < y
+		}
+		return x > y
+	}
+
+	if a.quake.Properties.Time != b.quake.Properties.Time {
+		return a.quake.Properties.Time > b.quake.Properties.Time
+	}
+
+	return a.quake.ID < b.quake.ID
+}
+
+// Get the magnitude of a quake for sorting, quakes without one sort as the smallest
+func sortableMagnitude(quake usgs.Feature) float64 {
+	mag, ok := quakeMagnitude(quake)
+	if !ok {
+		return math.Inf(-1)
+	}
+
+	return mag
+}
+
+// Get the depth of a quake for sorting, quakes without one sort as the shallowest
+func sortableDepth(quake usgs.Feature) float64 {
+	depth, ok := quakeDepth(quake)
+	if !ok {
+		return math.Inf(-1)
+	}
+
+	return depth
+}
+
+// Get the distance to a quake for sorting, quakes without coordinates sort as the furthest
+func (q *quakeTable) sortableDistance(row quakeRow) float64 {
+	distance, ok := q.distance(row)
+	if !ok {
+		return math.Inf(1)
+	}
+
+	return distance
+}
+
+// Draw the header row with an arrow on the column we're sorting by
+// Columns that can be sorted by are numbered with the key that sorts by them, eg: 3 Magnitude
+func (q *quakeTable) renderHeader() {
+	for position, column := range q.columns {
+		position := position
+		text := tableColumns[column].header
+		if q.sortable(column) && position < 9 {
+			text = fmt.Sprintf("[::d]%d[::-] %s", position+1, text)
+		}
+		if column == sortColumn[q.sortBy] {
+			if q.ascending {
+				text += " ▲"
+			} else {
+				text += " ▼"
+			}
+		}
+		q.table.SetCell(0,
+			position,
+			&tview.TableCell{
+				Text:          text,
+				Color:         tcell.ColorYellow,
+				Align:         tview.AlignCenter,
+				NotSelectable: true,
+				// Clicking a header sorts by that column without moving the selection
+				Clicked: func() bool {
+					q.headerSorted = true
+					q.sortByColumn(position)
+					return true
+				},
+			})
+	}
+}
+
+// Draw a quake into the given table row
+func (q *quakeTable) renderRow(atRow int, row quakeRow) {
+	rowColor := q.quakeColor(row.quake)
+
+	// Shallow quakes do the most damage, so we want them to stand out
+	depth, ok := quakeDepth(row.quake)
+	shallow := ok && depth < 10
+
+	// Quakes USGS has deleted are greyed out and crossed off, dismissed ones are just greyed out
+	deleted := row.quake.Properties.Status == "deleted"
+	dismissed := q.dismissed[row.quake.ID]
+
+	// New quakes get a background and a marker, updated ones just get a quieter marker
+	marker := q.marker(row)
+	background := tcell.ColorDefault
+	if marker == NEWMARKER {
+		background = tcell.ColorDarkSlateGray
+	}
+
+	// Quakes near home are bold all the way across, on top of their magnitude color
+	near := q.nearHome(row)
+
+	// Quakes about to age out are dimmed so it's less of a surprise when they go
+	expiring := q.expiringSoon(row)
+
+	// Tsunami flagged quakes are the most important thing in the feed, so they get a background
+	tsunami := row.quake.Properties.Tsunami == 1
+	if tsunami {
+		background = tcell.ColorDarkBlue
+	}
+
+	for position, column := range q.columns {
+		color := rowColor
+		align := tview.AlignLeft
+		switch column {
+		case columnID:
+			color = tcell.ColorDarkCyan
+		case columnAlert:
+			// The alert level is more meaningful than the magnitude, so it gets its own color
+			if alertColor, ok := alertColors[row.quake.Properties.Alert]; ok {
+				color = alertColor
+			}
+		case columnReviewed, columnStatus:
+			// Reviewed quakes are unlikely to change much more, so they're marked in green
+			if row.quake.Properties.Status == "reviewed" {
+				color = tcell.ColorGreen
+			}
+		}
+		var attributes tcell.AttrMask
+		if column == columnDepth && shallow {
+			attributes = tcell.AttrBold | tcell.AttrUnderline
+		}
+		if near {
+			attributes |= tcell.AttrBold
+		}
+		if expiring {
+			attributes |= tcell.AttrDim
+		}
+		if deleted || dismissed {
+			color = tcell.ColorGray
+			attributes = tcell.AttrDim
+		}
+		// The full quake is kept on the first cell so we can get back to it from the row
+		var reference interface{}
+		if position == 0 {
+			reference = row.quake
+		}
+		text := row.cells[column]
+		switch {
+		case column == columnTime:
+			text = q.timeText(row)
+		case column == columnUpdated:
+			text = updatedText(row.quake)
+		case column == columnCoordinates:
+			text = q.coordinatesText(row)
+		case column == columnDistance:
+			text = q.distanceText(row)
+		case column == columnMagnitude:
+			text += q.revisionText(row)
+		case column == columnLocation && deleted:
+			text = "✗ deleted: " + text
+		case column == columnLocation && tsunami:
+			text = "🌊 " + text
+		}
+		// Places and titles from the feed can have [brackets] that tview would read as color tags
+		text = tview.Escape(text)
+		if column == columnLocation && row.region != "" {
+			text = "📍 " + tview.Escape(row.region) + " · " + text
+		}
+		if column == columnLocation {
+			text = q.groupText(row, text)
+		}
+		if position == 0 {
+			text = marker + text
+		}
+		q.table.SetCell(atRow,
+			position,
+			&tview.TableCell{
+				Reference:       reference,
+				Text:            text,
+				Color:           color,
+				BackgroundColor: background,
+				Align:           align,
+				Attributes:      attributes,
+				NotSelectable:   column == columnID,
+			})
+	}
+}
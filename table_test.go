@@ -0,0 +1,243 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// Get the milliseconds since the epoch for a time, like the feeds use
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// Get the IDs of some rows, in order
+func rowIDs(rows []quakeRow) []string {
+	var ids []string
+	for _, row := range rows {
+		ids = append(ids, row.quake.ID)
+	}
+
+	return ids
+}
+
+func TestSortByTimeAcrossBoundaries(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	hawaii := time.FixedZone("HST", -10*60*60)
+
+	tests := []struct {
+		name   string
+		quakes map[string]time.Time
+		want   []string // Newest first
+	}{
+		{
+			// Shown in December, this is 1 January somewhere else
+			name: "new year",
+			quakes: map[string]time.Time{
+				"dec31": time.Date(2020, 12, 31, 23, 59, 30, 0, time.UTC),
+				"jan01": time.Date(2021, 1, 1, 0, 0, 15, 0, time.UTC),
+				"dec30": time.Date(2020, 12, 30, 12, 0, 0, 0, time.UTC),
+			},
+			want: []string{"jan01", "dec31", "dec30"},
+		},
+		{
+			// The Tokyo quake reads as the later day but happened first
+			name: "time zones",
+			quakes: map[string]time.Time{
+				"tokyo":  time.Date(2021, 3, 2, 8, 0, 0, 0, tokyo),
+				"hawaii": time.Date(2021, 3, 1, 14, 0, 0, 0, hawaii),
+				"utc":    time.Date(2021, 3, 1, 23, 30, 0, 0, time.UTC),
+			},
+			want: []string{"hawaii", "utc", "tokyo"},
+		},
+		{
+			name: "end of february",
+			quakes: map[string]time.Time{
+				"mar01": time.Date(2021, 3, 1, 0, 0, 1, 0, time.UTC),
+				"feb28": time.Date(2021, 2, 28, 23, 59, 59, 0, time.UTC),
+			},
+			want: []string{"mar01", "feb28"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := &quakeTable{sortBy: sortTime, location: tokyo}
+			for id, at := range test.quakes {
+				q.events.upsert(quakeRow{quake: testQuake(id, 3, millis(at))})
+			}
+
+			if got := rowIDs(q.sorted()); !equalStrings(got, test.want) {
+				t.Errorf("newest first = %v, want %v", got, test.want)
+			}
+
+			q.ascending = true
+			want := make([]string, len(test.want))
+			for i, id := range test.want {
+				want[len(want)-1-i] = id
+			}
+			if got := rowIDs(q.sorted()); !equalStrings(got, want) {
+				t.Errorf("oldest first = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// Get the widest the place cells can be once the table's fit to width
+func placeWidths(t *testing.T, q *quakeTable, width int) []int {
+	place := -1
+	for position, column := range q.columns {
+		if column == columnLocation {
+			place = position
+		}
+	}
+	q.fitPlaces(width)
+
+	var widths []int
+	for row := 1; row < q.table.GetRowCount(); row++ {
+		widths = append(widths, q.table.GetCell(row, place).MaxWidth)
+	}
+	if len(widths) == 0 {
+		t.Fatal("the table has no rows")
+	}
+
+	return widths
+}
+
+func TestFitPlacesMultibyte(t *testing.T) {
+	defer func(layout string) { timeLayout = layout }(timeLayout)
+
+	// Only the display width of the other columns counts, the places can be any length
+	places := []string{
+		"42 km WSW of Ferndale, California region, offshore segment",
+		"東京都八丈支庁八丈島東方沖",
+		"Ōfunato, Iwate, Japan — near coast 🌊",
+	}
+	at := millis(time.Date(2021, 3, 1, 14, 0, 0, 0, time.UTC))
+	fit := func(layout string, width int) []int {
+		timeLayout = layout
+		q := newQuakeTable(tview.NewTable(), []int{columnTime, columnMagnitude, columnLocation}, colorScale{}, nil, false)
+		q.location = time.UTC
+		var rows []quakeRow
+		for i, place := range places {
+			quake := testQuake(string(rune('a'+i)), 4, at-int64(i))
+			quake.Properties.Place = place
+			rows = append(rows, quakeRow{quake: quake, cells: formatRow(quake)})
+		}
+		q.restore(rows)
+
+		return placeWidths(t, q, width)
+	}
+
+	// 年, 月, and 日 are two columns wide each, so the places lose 4 more columns than with dashes,
+	// not the 7 extra bytes or 1 fewer rune
+	ascii := fit("2006-01-02 15:04", 100)
+	wide := fit("2006年01月02日 15:04", 100)
+	for i := range ascii {
+		if ascii[i] != ascii[0] || wide[i] != wide[0] {
+			t.Fatalf("place widths differ between rows: %v, %v", ascii, wide)
+		}
+	}
+	if got, want := ascii[0]-wide[0], 4; got != want {
+		t.Errorf("wide time layout took %d more columns from the places, want %d", got, want)
+	}
+
+	// Too narrow to fit and the places are still readable
+	if got := fit("2006年01月02日 15:04", 20); got[0] != MINPLACEWIDTH {
+		t.Errorf("narrow place width = %d, want %d", got[0], MINPLACEWIDTH)
+	}
+}
+
+func TestRenderRowEscapesTags(t *testing.T) {
+	q := newQuakeTable(tview.NewTable(), []int{columnID, columnMagnitude, columnLocation}, colorScale{}, nil, false)
+	tests := []string{
+		"Mid-Atlantic Ridge [red] segment",
+		"[::b]Bold[::-] Island",
+		"Reykjanes [Iceland]",
+	}
+
+	for _, place := range tests {
+		quake := testQuake("a", 4, 1000)
+		quake.Properties.Place = place
+		q.restore([]quakeRow{{quake: quake, cells: formatRow(quake)}})
+
+		// Shown as it is, so it's as wide as the place itself
+		text := q.table.GetCell(1, 2).Text
+		if got := tview.TaggedStringWidth(text); got != len(place) {
+			t.Errorf("place %q is shown %d wide as %q, want %d", place, got, text, len(place))
+		}
+	}
+}
+
+func TestDetailsHaveWholePlace(t *testing.T) {
+	for _, place := range []string{
+		"42 km WSW of Ferndale, California region, offshore segment",
+		"東京都八丈支庁八丈島東方沖",
+	} {
+		quake := testQuake("jp1", 5, 1000)
+		quake.Properties.Place = place
+		if details := formatDetails(quake, time.UTC); !strings.Contains(details, place) {
+			t.Errorf("details don't have the whole place %q:\n%s", place, details)
+		}
+	}
+}
+
+func TestSortTiesAreStable(t *testing.T) {
+	q := &quakeTable{sortBy: sortMagnitude}
+	for _, id := range []string{"c", "a", "b"} {
+		q.events.upsert(quakeRow{quake: testQuake(id, 3, 1000)})
+	}
+	q.events.upsert(quakeRow{quake: testQuake("older", 3, 500)})
+
+	// Same magnitude, so newest first and then by ID, whichever order they came in
+	if got, want := rowIDs(q.sorted()), []string{"a", "b", "c", "older"}; !equalStrings(got, want) {
+		t.Errorf("sorted = %v, want %v", got, want)
+	}
+}
+
+func TestSortSurvivesRefresh(t *testing.T) {
+	q := newQuakeTable(tview.NewTable(), []int{columnTime, columnMagnitude, columnLocation}, colorScale{}, nil, false)
+	row := func(id string, mag float64, millis int64) quakeRow {
+		quake := testQuake(id, mag, millis)
+		return quakeRow{quake: quake, cells: formatRow(quake)}
+	}
+	q.restore([]quakeRow{row("small", 2, 3000), row("big", 6, 1000), row("medium", 4, 2000)})
+
+	// Pressing 2 sorts by the second column, and again flips it
+	q.sortByColumn(1)
+	if got, want := rowIDs(q.shown), []string{"big", "medium", "small"}; !equalStrings(got, want) {
+		t.Fatalf("by magnitude = %v, want %v", got, want)
+	}
+	q.selectID("medium")
+
+	// New quakes go where they belong instead of the table going back to time order, and the
+	// selection stays on the same quake
+	revised := row("small", 5, 3000)
+	revised.quake.Properties.Updated = 4000
+	q.apply([]quakeRow{row("newest", 3, 5000), row("tie", 4, 2500), revised}, nil)
+	if got, want := rowIDs(q.shown), []string{"big", "small", "tie", "medium", "newest"}; !equalStrings(got, want) {
+		t.Errorf("after a refresh = %v, want %v", got, want)
+	}
+	if got := q.selectedID(); got != "medium" {
+		t.Errorf("selected %q after a refresh, want medium", got)
+	}
+
+	// Smallest first keeps the newest first between equal magnitudes
+	q.sortByColumn(1)
+	if got, want := rowIDs(q.shown), []string{"newest", "tie", "medium", "small", "big"}; !equalStrings(got, want) {
+		t.Errorf("flipped = %v, want %v", got, want)
+	}
+	q.apply([]quakeRow{row("another", 1, 6000)}, []string{"big"})
+	if got, want := rowIDs(q.shown), []string{"another", "newest", "tie", "medium", "small"}; !equalStrings(got, want) {
+		t.Errorf("flipped after a refresh = %v, want %v", got, want)
+	}
+
+	// Columns that can't be sorted by don't change anything
+	q.sortByColumn(2)
+	q.sortByColumn(7)
+	if q.sortBy != sortMagnitude || !q.ascending {
+		t.Errorf("sort = %d ascending %v, want by magnitude smallest first", q.sortBy, q.ascending)
+	}
+}
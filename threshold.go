@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math" // Needed to snap the threshold to a step
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// How far '+' and '-' move the minimum magnitude
+const MAGSTEP = 0.5
+
+// Highest '+' will take the minimum magnitude
+const MAXMINMAG = 9.0
+
+// Check if a quake is at or above the minimum magnitude picked with '+' and '-'
+// Like -min-magnitude, quakes without a magnitude are treated as below any positive threshold
+func (q *quakeTable) aboveMinMag(quake usgs.Feature) bool {
+	if q.minMag <= 0 {
+		return true
+	}
+
+	mag, ok := quakeMagnitude(quake)
+	return ok && mag >= q.minMag
+}
+
+// Raise the minimum magnitude to the next step, hiding the quakes below it
+func (q *quakeTable) raiseMinMag() {
+	q.setMinMag(math.Floor(q.minMag/MAGSTEP)*MAGSTEP + MAGSTEP)
+}
+
+// Lower the minimum magnitude to the step below, bringing back the quakes it hid
+// It can't go under -min-magnitude, quakes below that were never fetched
+func (q *quakeTable) lowerMinMag() {
+	q.setMinMag(math.Ceil(q.minMag/MAGSTEP)*MAGSTEP - MAGSTEP)
+}
+
+// Change the minimum magnitude, the quakes are all still there so it's only a redraw
+func (q *quakeTable) setMinMag(minMag float64) {
+	minMag = math.Max(q.fetchMinMag, math.Min(minMag, MAXMINMAG))
+	if minMag == q.minMag {
+		return
+	}
+
+	q.minMag = minMag
+	q.rerender()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"sort"    // Needed to list the presets in order
+	"strconv" // Needed for unix times
+	"strings" // Needed to list the presets
+	"time"    // Needed to check layouts
+)
+
+// Stands in for a layout to show times as seconds since the epoch, which Go layouts can't do
+const TIMEUNIX = "unix"
+
+// Named formats for -time-format, anything else is taken as a Go layout
+// relative isn't a layout, it turns on relative times like -time relative
+var timePresets = map[string]string{
+	"default":  TIMEFORMAT,
+	"iso8601":  "2006-01-02T15:04:05-07:00",
+	"rfc3339":  time.RFC3339,
+	"unix":     TIMEUNIX,
+	"relative": "",
+}
+
+// How times are formatted everywhere they're shown, set once from -time-format before anything's drawn
+var timeLayout = TIMEFORMAT
+
+// How times are formatted in lines meant for scripts, ie: -plain, diff, and watch, which are always in UTC
+// They stay RFC 3339 unless -time-format is given, JSON output is always RFC 3339
+var scriptLayout = time.RFC3339
+
+// Parse -time-format into the layout to use, and whether it asked for relative times
+// A Go layout has to have something in it that changes with the time, eg: 15:04, so a typo'd preset
+// isn't taken as a layout that prints the same text for every quake
+func parseTimeFormat(value string) (string, bool, error) {
+	if layout, ok := timePresets[strings.ToLower(value)]; ok {
+		if layout == "" {
+			return TIMEFORMAT, true, nil
+		}
+		return layout, false, nil
+	}
+
+	reference := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if reference.Format(value) == value {
+		var presets []string
+		for name := range timePresets {
+			presets = append(presets, name)
+		}
+		sort.Strings(presets)
+		return "", false, fmt.Errorf("invalid time format %q: must be one of %s, or a Go layout, eg: \"2006-01-02 15:04 MST\"", value, strings.Join(presets, ", "))
+	}
+
+	return value, false, nil
+}
+
+// Format a time with a layout from -time-format
+func formatLayout(t time.Time, layout string) string {
+	if layout == TIMEUNIX {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+
+	return t.Format(layout)
+}
+
+// Format a USGS timestamp for a line meant for scripts
+func formatScriptTime(millis int64) string {
+	return formatLayout(fromMillis(millis).UTC(), scriptLayout)
+}
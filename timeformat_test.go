@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeFormat(t *testing.T) {
+	tests := []struct {
+		value    string
+		layout   string
+		relative bool
+		fails    bool
+	}{
+		{value: "default", layout: TIMEFORMAT},
+		{value: "ISO8601", layout: "2006-01-02T15:04:05-07:00"},
+		{value: "rfc3339", layout: time.RFC3339},
+		{value: "unix", layout: TIMEUNIX},
+		{value: "relative", layout: TIMEFORMAT, relative: true},
+		{value: "2006-01-02 15:04", layout: "2006-01-02 15:04"},
+		{value: "relativ", fails: true},
+		{value: "unixtime", fails: true},
+		{value: "", fails: true},
+	}
+
+	for _, test := range tests {
+		layout, relative, err := parseTimeFormat(test.value)
+		switch {
+		case test.fails && err == nil:
+			t.Errorf("parseTimeFormat(%q) = %q, want an error", test.value, layout)
+		case !test.fails && err != nil:
+			t.Errorf("parseTimeFormat(%q) failed: %v", test.value, err)
+		case layout != test.layout || relative != test.relative:
+			t.Errorf("parseTimeFormat(%q) = %q, %v, want %q, %v", test.value, layout, relative, test.layout, test.relative)
+		}
+	}
+}
+
+func TestFormatScriptTime(t *testing.T) {
+	defer func(layout string) { scriptLayout = layout }(scriptLayout)
+	millis := int64(1700000000123)
+
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{time.RFC3339, "2023-11-14T22:13:20Z"},
+		{"2006-01-02T15:04:05-07:00", "2023-11-14T22:13:20+00:00"},
+		{TIMEUNIX, "1700000000"},
+		{"Jan 2 15:04 MST", "Nov 14 22:13 UTC"},
+	}
+	for _, test := range tests {
+		scriptLayout = test.layout
+		if got := formatScriptTime(millis); got != test.want {
+			t.Errorf("formatScriptTime with %q = %q, want %q", test.layout, got, test.want)
+		}
+	}
+
+	scriptLayout = time.RFC3339
+	if got, want := formatPlain(testQuake("us1", 4.2, millis)), "us1\t2023-11-14T22:13:20Z\t4.20\t10.0\tTest place us1"; got != want {
+		t.Errorf("formatPlain = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"     // Needed for errors
+	"strconv" // Needed to parse the minimum magnitude
+	"strings" // Needed to split the regions
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// A named area to always show quakes from, whatever the other filters say
+// It's either a radius or a box, and can have its own minimum magnitude
+type watchRegion struct {
+	name         string
+	spec         string // What was given for it, so -write-config can write it back
+	near         *geoRadius
+	box          *geoBox
+	minMagnitude float64 // 0 shows every quake in the region
+}
+
+// Watch regions from -watch, in the order they were given so the first match wins
+type watchList []watchRegion
+
+func (w *watchList) String() string {
+	var specs []string
+	for _, region := range *w {
+		specs = append(specs, region.spec)
+	}
+
+	return strings.Join(specs, ";")
+}
+
+// Regions can be separated with ; and -watch can be repeated, eg: in the config file
+func (w *watchList) Set(value string) error {
+	for _, spec := range strings.Split(value, ";") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		region, err := parseWatchRegion(spec)
+		if err != nil {
+			return err
+		}
+		*w = append(*w, region)
+	}
+
+	return nil
+}
+
+// Needed so -write-config quotes the list
+func (w *watchList) Get() interface{} {
+	return w.String()
+}
+
+// Get the name of the first region a quake is in, or "" if it isn't in any
+func (w watchList) match(quake usgs.Feature) string {
+	for _, region := range w {
+		if region.contains(quake) {
+			return region.name
+		}
+	}
+
+	return ""
+}
+
+// Parse a watch region like name=near:lat,lon,km or name=bbox:minLat,minLon,maxLat,maxLon,
+// with an optional :mag on the end for the smallest quakes to show
+func parseWatchRegion(spec string) (watchRegion, error) {
+	region := watchRegion{spec: spec}
+
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return region, fmt.Errorf("invalid watch region %q: expected name=near:lat,lon,km or name=bbox:minLat,minLon,maxLat,maxLon", spec)
+	}
+	region.name = strings.TrimSpace(parts[0])
+
+	area := strings.Split(strings.TrimSpace(parts[1]), ":")
+	if len(area) == 3 {
+		mag, err := strconv.ParseFloat(strings.TrimSpace(area[2]), 64)
+		if err != nil {
+			return region, fmt.Errorf("invalid watch region %q: %q is not a magnitude", spec, area[2])
+		}
+		region.minMagnitude = mag
+		area = area[:2]
+	}
+	if len(area) != 2 {
+		return region, fmt.Errorf("invalid watch region %q: expected near: or bbox: before the coordinates", spec)
+	}
+
+	switch strings.TrimSpace(area[0]) {
+	case "near":
+		near, err := parseRadius(area[1])
+		if err != nil {
+			return region, fmt.Errorf("invalid watch region %q: %v", spec, err)
+		}
+		region.near = &near
+	case "bbox":
+		box, err := parseBoundingBox(area[1])
+		if err != nil {
+			return region, fmt.Errorf("invalid watch region %q: %v", spec, err)
+		}
+		region.box = box
+	default:
+		return region, fmt.Errorf("invalid watch region %q: the area must be near: or bbox:", spec)
+	}
+
+	return region, nil
+}
+
+// Check if a quake is in the region and big enough, quakes without coordinates never are
+// Quakes without a magnitude only count when the region has no minimum
+func (r watchRegion) contains(quake usgs.Feature) bool {
+	if r.minMagnitude > 0 {
+		mag, ok := quakeMagnitude(quake)
+		if !ok || mag < r.minMagnitude {
+			return false
+		}
+	}
+
+	if r.near != nil {
+		return r.near.contains(quake)
+	}
+
+	return r.box != nil && r.box.contains(quake)
+}
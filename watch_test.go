@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+	"github.com/rivo/tview"
+)
+
+// Make a quake with a magnitude at a place, nil leaves the magnitude out
+func watchQuake(lat, lon float64, mag *float64) usgs.Feature {
+	quake := quakeAt(lat, lon)
+	quake.Properties.Mag = mag
+
+	return quake
+}
+
+func TestWatchListMatch(t *testing.T) {
+	var regions watchList
+	for _, spec := range []string{
+		"Tokyo=near:35.68,139.69,100:2.5",
+		"Aleutians=bbox:50,170,60,-170",
+		"Japan=bbox:24,122,46,146",
+		"Pole=bbox:80,-180,90,180",
+	} {
+		if err := regions.Set(spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mag := func(m float64) *float64 { return &m }
+
+	tests := []struct {
+		name  string
+		quake usgs.Feature
+		want  string
+	}{
+		{"center", watchQuake(35.68, 139.69, mag(3)), "Tokyo"},
+		{"first match wins", watchQuake(35.9, 140, mag(3)), "Tokyo"},
+		{"too small for the first", watchQuake(35.9, 140, mag(1)), "Japan"},
+		{"no magnitude for the first", watchQuake(35.9, 140, nil), "Japan"},
+		{"just inside the radius", watchQuake(35.68+0.89, 139.69, mag(3)), "Tokyo"}, // About 99 km north
+		{"just outside the radius", watchQuake(35.68+0.91, 139.69, mag(3)), "Japan"},
+		{"box corner", watchQuake(24, 122, nil), "Japan"},
+		{"box edge", watchQuake(46, 130, nil), "Japan"},
+		{"past the box edge", watchQuake(46.001, 130, nil), ""},
+		{"across the antimeridian west", watchQuake(55, 175, nil), "Aleutians"},
+		{"across the antimeridian east", watchQuake(55, -175, nil), "Aleutians"},
+		{"on the antimeridian", watchQuake(55, 180, nil), "Aleutians"},
+		{"outside the wrapped box", watchQuake(55, -160, nil), ""},
+		{"north pole", watchQuake(90, 0, nil), "Pole"},
+		{"no coordinates", usgs.Feature{ID: "test"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := regions.match(test.quake); got != test.want {
+				t.Errorf("match = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseWatchRegion(t *testing.T) {
+	tests := []struct {
+		spec    string
+		name    string
+		minMag  float64
+		wantErr string
+	}{
+		{spec: "Family=near:35.68,139.69,50", name: "Family"},
+		{spec: " Home = bbox:24,122,46,146:3.5 ", name: "Home", minMag: 3.5},
+		{spec: "near:35.68,139.69,50", wantErr: "expected name="},
+		{spec: "=near:35.68,139.69,50", wantErr: "expected name="},
+		{spec: "Home=35.68,139.69,50", wantErr: "near: or bbox:"},
+		{spec: "Home=circle:35.68,139.69,50", wantErr: "must be near: or bbox:"},
+		{spec: "Home=near:35.68,139.69", wantErr: "lat,lon,km"},
+		{spec: "Home=near:35.68,139.69,50:big", wantErr: "not a magnitude"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			region, err := parseWatchRegion(test.spec)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to mention %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if region.name != test.name || region.minMagnitude != test.minMag || region.spec != test.spec {
+				t.Errorf("region = %+v, want %q with minimum %v", region, test.name, test.minMag)
+			}
+		})
+	}
+}
+
+func TestWatchListSetSeparators(t *testing.T) {
+	var regions watchList
+	if err := regions.Set("A=near:1,2,3; ;B=bbox:1,2,3,4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := regions.Set("C=near:4,5,6"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := regions.String(), "A=near:1,2,3;B=bbox:1,2,3,4;C=near:4,5,6"; got != want {
+		t.Errorf("regions = %q, want %q", got, want)
+	}
+}
+
+func TestWatchedQuakesArePinned(t *testing.T) {
+	q := newQuakeTable(tview.NewTable(), []int{columnMagnitude, columnLocation}, colorScale{}, nil, false)
+	q.filter = func(usgs.Feature) bool { return false } // Hide everything that isn't watched
+
+	newest := testQuake("newest", 4, 3000)
+	watched := testQuake("watched", 1, 1000)
+	q.restore([]quakeRow{
+		{quake: newest, cells: formatRow(newest)},
+		{quake: watched, cells: formatRow(watched), region: "Tokyo"},
+	})
+
+	if got, want := rowIDs(q.shown), []string{"watched"}; !equalStrings(got, want) {
+		t.Errorf("shown = %v, want %v", got, want)
+	}
+	if got := q.table.GetCell(1, 1).Text; !strings.Contains(got, "Tokyo") {
+		t.Errorf("place cell = %q, want the region name in it", got)
+	}
+
+	// Without the filter the watched quake still comes first, ahead of newer ones
+	q.filter = nil
+	q.render()
+	if got, want := rowIDs(q.shown), []string{"watched", "newest"}; !equalStrings(got, want) {
+		t.Errorf("shown = %v, want %v", got, want)
+	}
+}
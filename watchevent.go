@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"   // Needed to stop polling on timeout or interrupt
+	"errors"    // Needed to spot deleted events
+	"flag"      // Needed to parse the watch options
+	"fmt"       // Needed for printing
+	"io"        // Needed to write the revisions
+	"os"        // Needed for the interrupt signal
+	"os/signal" // Needed to stop cleanly when interrupted
+	"strings"   // Needed to keep tabs out of the output
+	"syscall"   // Needed for SIGTERM
+	"time"      // Needed to poll and format the revision times
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Run the watch subcommand, printing each revision of one event until USGS reviews it
+// Returns the exit code, 0 once the event is reviewed and 1 if it's deleted, or we time out
+func runWatchEvent(args []string, w, errs io.Writer) int {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(errs)
+	flags.Usage = func() {
+		fmt.Fprintln(errs, "Usage: QuakeCLI watch [options] <eventid>")
+		flags.PrintDefaults()
+	}
+	refresh := flags.Duration("refresh", time.Minute, "How often to check the event (minimum 15s)")
+	timeout := flags.Duration("timeout", 24*time.Hour, "Give up if the event still isn't reviewed after this long")
+	timeFormat := flags.String("time-format", "rfc3339", "How to show the update times in UTC: iso8601, rfc3339, unix, or a Go layout, eg: \"2006-01-02 15:04\"")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	layout, relative, err := parseTimeFormat(*timeFormat)
+	if err == nil && relative {
+		err = fmt.Errorf("invalid time format %q: watch prints when each revision happened, so it can't be relative", *timeFormat)
+	}
+	if err != nil {
+		fmt.Fprintln(errs, err)
+		return 2
+	}
+	scriptLayout = layout
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if *refresh < MINREFRESH {
+		fmt.Fprintf(errs, "invalid refresh interval %s: must be at least %s\n", *refresh, MINREFRESH)
+		return 2
+	}
+
+	httpClient, err := newHTTPClient(HTTPTIMEOUT, "", false)
+	if err != nil {
+		fmt.Fprintln(errs, err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return watchEvent(ctx, w, errs, usgs.NewClient(httpClient, FDSNAPI), flags.Arg(0), *refresh)
+}
+
+// Poll an event, printing it each time it's revised, until it's reviewed, deleted, or ctx is done
+func watchEvent(ctx context.Context, w, errs io.Writer, client *usgs.Client, id string, refresh time.Duration) int {
+	var lastUpdated int64
+	for {
+		event, err := client.GetEvent(ctx, id)
+		switch {
+		case errors.Is(err, usgs.ErrEventGone):
+			fmt.Fprintf(errs, "event %s was deleted or doesn't exist: %v\n", id, err)
+			return 1
+		case err != nil && ctx.Err() == nil:
+			// Keep trying, it'll probably work next time
+			fmt.Fprintln(errs, "fetch failed:", describeFetchError(err))
+		case err == nil && event.Properties.Updated != lastUpdated:
+			lastUpdated = event.Properties.Updated
+			if _, err := fmt.Fprintln(w, formatRevision(event)); err != nil {
+				fmt.Fprintln(errs, err)
+				return 1
+			}
+
+			switch event.Properties.Status {
+			case "reviewed":
+				return 0
+			case "deleted":
+				fmt.Fprintf(errs, "event %s was deleted\n", id)
+				return 1
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				fmt.Fprintf(errs, "event %s still wasn't reviewed when we gave up\n", id)
+			}
+			return 1
+		case <-time.After(refresh):
+		}
+	}
+}
+
+// Format a revision of an event as a tab separated line: updated, magnitude, magnitude type,
+// status, felt reports
+func formatRevision(event usgs.Feature) string {
+	fields := []string{
+		formatScriptTime(event.Properties.Updated),
+		formatMagnitude(event),
+		formatMagType(event.Properties.MagType),
+		event.Properties.Status,
+		fmt.Sprintf("%d felt", event.Properties.Felt),
+	}
+	for i := range fields {
+		fields[i] = strings.Replace(fields[i], "\t", " ", -1)
+	}
+
+	return strings.Join(fields, "\t")
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"         // Needed to send the payload
+	"encoding/json" // Needed to build the payload
+	"fmt"           // Needed for errors
+	"net/http"      // Needed to POST to the webhook
+	"sync"          // Needed to wait for POSTs that are still going
+	"time"          // Needed for timeouts and backoff
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+const (
+	// How long a webhook can take to answer before we give up on it
+	WEBHOOKTIMEOUT = 10 * time.Second
+
+	// How many times to retry a webhook that failed with a server error
+	WEBHOOKRETRIES = 3
+)
+
+// POSTs big quakes to a URL, eg: for home automation
+type webhook struct {
+	url     string
+	minMag  float64
+	client  *http.Client
+	failed  func(error)    // Called when a POST fails for good
+	posting sync.WaitGroup // POSTs still going, so they aren't cut off when we exit
+}
+
+// What gets POSTed for each quake
+type webhookPayload struct {
+	ID          string    `json:"id"`
+	Time        string    `json:"time"`
+	Mag         *float64  `json:"mag"`
+	Place       string    `json:"place"`
+	Coordinates []float64 `json:"coordinates"` // Longitude, latitude like GeoJSON
+	Depth       *float64  `json:"depth"`
+	URL         string    `json:"url"`
+	Alert       string    `json:"alert"`
+	Tsunami     bool      `json:"tsunami"`
+}
+
+// Create a webhook for quakes at or above minMag
+func newWebhook(url string, minMag float64, failed func(error)) *webhook {
+	return &webhook{
+		url:    url,
+		minMag: minMag,
+		client: &http.Client{Timeout: WEBHOOKTIMEOUT},
+		failed: failed,
+	}
+}
+
+// Build the payload for a quake
+func newWebhookPayload(quake usgs.Feature) webhookPayload {
+	payload := webhookPayload{
+		ID:      quake.ID,
+		Time:    fromMillis(quake.Properties.Time).UTC().Format(time.RFC3339),
+		Mag:     quake.Properties.Mag,
+		Place:   quake.Properties.Place,
+		URL:     quake.Properties.URL,
+		Alert:   quake.Properties.Alert,
+		Tsunami: quake.Properties.Tsunami == 1,
+	}
+
+	coords := quake.Geometry.Coordinates
+	if len(coords) >= 2 {
+		payload.Coordinates = coords[:2]
+	}
+	if depth, ok := quakeDepth(quake); ok {
+		payload.Depth = &depth
+	}
+
+	return payload
+}
+
+// Check if a quake should be sent, without a minimum magnitude every new quake is
+func (w *webhook) wants(quake usgs.Feature, previous *usgs.Feature) bool {
+	if w.minMag <= 0 {
+		return previous == nil
+	}
+
+	return crossedThreshold(w.minMag, quake, previous)
+}
+
+// POST a quake to the webhook in the background
+// This is called from the update goroutine, so it mustn't hold up the table
+func (w *webhook) send(quake usgs.Feature) {
+	w.posting.Add(1)
+	go func() {
+		defer w.posting.Done()
+		if err := w.post(newWebhookPayload(quake)); err != nil && w.failed != nil {
+			w.failed(fmt.Errorf("webhook for %s failed: %v", quake.ID, err))
+		}
+	}()
+}
+
+// Wait for POSTs that are still going, eg: before a one-shot run exits
+func (w *webhook) Close() {
+	if w == nil {
+		return
+	}
+
+	waitPosts(&w.posting)
+}
+
+// Wait for background POSTs to finish, giving up if they take too long so quitting isn't held up
+// by a server that's gone
+func waitPosts(posting *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		posting.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(WEBHOOKTIMEOUT):
+	}
+}
+
+// POST a payload, retrying with backoff if the server has an error or can't be reached
+func (w *webhook) post(payload webhookPayload) error {
+	return postJSON(w.client, w.url, payload)
+}
+
+// POST a payload as JSON, retrying with backoff if the server has an error or can't be reached
+// Shared by the webhook and the chat webhooks
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		err = postOnce(client, url, body)
+		if err == nil || attempt >= WEBHOOKRETRIES {
+			return err
+		}
+		if statusErr, ok := err.(webhookStatusError); ok && statusErr < 500 {
+			return err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// A webhook answered with a status that wasn't 2xx
+type webhookStatusError int
+
+func (e webhookStatusError) Error() string {
+	return fmt.Sprintf("%d %s", int(e), http.StatusText(int(e)))
+}
+
+// POST a body once
+func postOnce(client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", usgs.USERAGENT)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return webhookStatusError(resp.StatusCode)
+	}
+
+	return nil
+}
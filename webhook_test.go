@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HelixSpiral/EarthquakeCLI/internal/usgs"
+)
+
+// Start a server that takes a while to answer each POST, counting the ones it answered
+func slowServer(t *testing.T, received *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		atomic.AddInt32(received, 1)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestWebhookCloseWaitsForPosts(t *testing.T) {
+	var received int32
+	server := slowServer(t, &received)
+
+	w := newWebhook(server.URL, 0, func(err error) { t.Error(err) })
+	w.send(testQuake("a", 5, 1000))
+	w.send(testQuake("b", 5, 2000))
+	w.Close()
+
+	if got := atomic.LoadInt32(&received); got != 2 {
+		t.Errorf("server got %d POSTs before Close returned, want 2", got)
+	}
+}
+
+func TestChatWebhookCloseWaitsForPosts(t *testing.T) {
+	var received int32
+	server := slowServer(t, &received)
+
+	c := newChatWebhook(server.URL, chatSlack, 0, nil, time.UTC, func(err error) { t.Error(err) })
+	c.send(testQuake("a", 5, 1000))
+	c.flush()
+	c.Close()
+
+	if got := atomic.LoadInt32(&received); got != 1 {
+		t.Errorf("server got %d POSTs before Close returned, want 1", got)
+	}
+}
+
+func TestCloseWithoutWebhooks(t *testing.T) {
+	var w *webhook
+	var c *chatWebhook
+	w.Close()
+	c.Close()
+}
+
+func TestWebhookPayload(t *testing.T) {
+	bodies := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		bodies <- body
+	}))
+	defer server.Close()
+
+	quake := testQuake("nc73524811", 5.2, 1614834116240)
+	quake.Properties.URL = "https://earthquake.usgs.gov/earthquakes/eventpage/nc73524811"
+	quake.Properties.Alert = "yellow"
+	quake.Properties.Tsunami = 1
+	if err := newWebhook(server.URL, 0, nil).post(newWebhookPayload(quake)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"id":          "nc73524811",
+		"time":        "2021-03-04T05:01:56Z",
+		"mag":         5.2,
+		"place":       "Test place nc73524811",
+		"coordinates": []interface{}{-122.8, 38.8},
+		"depth":       10.0,
+		"url":         "https://earthquake.usgs.gov/earthquakes/eventpage/nc73524811",
+		"alert":       "yellow",
+		"tsunami":     true,
+	}
+	if got := <-bodies; !reflect.DeepEqual(got, want) {
+		t.Errorf("payload = %v\nwant %v", got, want)
+	}
+
+	// Unknown values are null rather than 0
+	quake.Properties.Mag = nil
+	quake.Geometry.Coordinates = nil
+	payload, _ := json.Marshal(newWebhookPayload(quake))
+	for _, field := range []string{`"mag":null`, `"coordinates":null`, `"depth":null`} {
+		if !strings.Contains(string(payload), field) {
+			t.Errorf("payload %s doesn't have %s", payload, field)
+		}
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int32
+		wantErr  bool
+	}{
+		{"server error then fine", []int{http.StatusBadGateway, http.StatusOK}, 2, false},
+		{"client errors aren't retried", []int{http.StatusBadRequest, http.StatusOK}, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statuses[atomic.AddInt32(&requests, 1)-1])
+			}))
+			defer server.Close()
+
+			err := newWebhook(server.URL, 0, nil).post(newWebhookPayload(testQuake("a", 5, 1000)))
+			if (err != nil) != test.wantErr {
+				t.Errorf("err = %v, want an error: %v", err, test.wantErr)
+			}
+			if got := atomic.LoadInt32(&requests); got != test.requests {
+				t.Errorf("server got %d requests, want %d", got, test.requests)
+			}
+		})
+	}
+}
+
+func TestWebhookFailuresAreReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	failures := make(chan error, 1)
+	w := newWebhook(server.URL, 0, func(err error) { failures <- err })
+	w.send(testQuake("a", 5, 1000))
+	w.Close()
+
+	select {
+	case err := <-failures:
+		if !strings.Contains(err.Error(), "403 Forbidden") {
+			t.Errorf("failure = %v, want it to say 403 Forbidden", err)
+		}
+	default:
+		t.Error("the failure wasn't reported")
+	}
+}
+
+func TestCrossedThreshold(t *testing.T) {
+	small, big := testQuake("a", 3, 1000), testQuake("a", 5, 1000)
+	noMag := testQuake("a", 0, 1000)
+	noMag.Properties.Mag = nil
+
+	tests := []struct {
+		name     string
+		quake    usgs.Feature
+		previous *usgs.Feature
+		want     bool
+	}{
+		{"new and big", big, nil, true},
+		{"new and small", small, nil, false},
+		{"revised up past it", big, &small, true},
+		{"already past it", big, &big, false},
+		{"got a magnitude", big, &noMag, true},
+		{"no magnitude", noMag, nil, false},
+	}
+
+	for _, test := range tests {
+		if got := crossedThreshold(4.5, test.quake, test.previous); got != test.want {
+			t.Errorf("%s: crossedThreshold = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"  // Needed for the caption
+	"math" // Needed to wrap longitudes
+	"sort" // Needed to draw the biggest quakes on top
+
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+// Markers for quakes on the map
+const (
+	MAPQUAKE    = '●'
+	MAPSELECTED = '◉'
+)
+
+// A world map plotting the quakes in the table, drawn as braille dots so the coastlines are
+// four times sharper than the terminal's cells
+// It's drawn straight from the table's rows, so it stays up to date as the table changes
+type quakeMap struct {
+	*tview.Box
+	quakes *quakeTable
+
+	// The land mask for the last size we drew at, one entry per braille dot
+	land         []bool
+	landW, landH int
+}
+
+func newQuakeMap(quakes *quakeTable) *quakeMap {
+	m := &quakeMap{Box: tview.NewBox(), quakes: quakes}
+	m.SetBorder(true).SetTitle(" Map (Tab for the table) ")
+
+	return m
+}
+
+// Draw the map, the quakes on it, and the selected quake's place underneath
+func (m *quakeMap) Draw(screen tcell.Screen) {
+	m.Box.Draw(screen)
+	x, y, width, height := m.GetInnerRect()
+
+	// Keep a line for the caption, and keep degrees square by making the map 4 times as wide as
+	// it is tall, since cells are about twice as tall as they are wide
+	height--
+	if width <= 0 || height <= 0 {
+		return
+	}
+	mapW, mapH := width, (width+3)/4
+	if mapH > height {
+		mapW, mapH = height*4, height
+	}
+	left := x + (width-mapW)/2
+
+	// Draw the land, only working out the mask again if the size changed
+	if m.landW != mapW*2 || m.landH != mapH*4 {
+		m.land = landMask(mapW*2, mapH*4)
+		m.landW, m.landH = mapW*2, mapH*4
+	}
+	landStyle := tcell.StyleDefault.Foreground(tcell.ColorDarkGreen)
+	for row := 0; row < mapH; row++ {
+		for col := 0; col < mapW; col++ {
+			if dots := m.brailleCell(col, row); dots != 0 {
+				screen.SetContent(left+col, y+row, 0x2800+dots, nil, landStyle)
+			}
+		}
+	}
+
+	// Biggest quakes last so they aren't hidden by smaller ones nearby
+	rows := make([]quakeRow, len(m.quakes.shown))
+	copy(rows, m.quakes.shown)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return sortableMagnitude(rows[i].quake) < sortableMagnitude(rows[j].quake)
+	})
+
+	selectedID := m.quakes.selectedID()
+	var selected *quakeRow
+	for i, row := range rows {
+		if row.quake.ID == selectedID {
+			selected = &rows[i]
+			continue
+		}
+		m.plot(screen, row, left, y, mapW, mapH, MAPQUAKE, tcell.StyleDefault)
+	}
+
+	caption := "No quake selected"
+	if selected != nil {
+		m.plot(screen, *selected, left, y, mapW, mapH, MAPSELECTED, tcell.StyleDefault.Reverse(true))
+		caption = fmt.Sprintf("%s · M%s · %s", tview.Escape(quakePlace(selected.quake)), formatMagnitude(selected.quake), formatTime(selected.quake.Properties.Time, m.quakes.location))
+	}
+	tview.Print(screen, caption, x, y+height, width, tview.AlignCenter, tcell.ColorWhite)
+}
+
+// Plot a quake on the map in the table's colors, quakes without coordinates are skipped
+func (m *quakeMap) plot(screen tcell.Screen, row quakeRow, left, top, mapW, mapH int, marker rune, style tcell.Style) {
+	point, ok := quakePoint(row.quake)
+	if !ok {
+		return
+	}
+
+	col, line := mapCell(point, mapW, mapH)
+	screen.SetContent(left+col, top+line, marker, nil, style.Foreground(m.quakes.quakeColor(row.quake)))
+}
+
+// Get the braille dots for a cell from the land mask
+func (m *quakeMap) brailleCell(col, row int) rune {
+	// The bit for each dot, by column then row within the cell
+	bits := [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+	var dots rune
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < 4; dy++ {
+			if m.land[(row*4+dy)*m.landW+col*2+dx] {
+				dots |= bits[dx][dy]
+			}
+		}
+	}
+
+	return dots
+}
+
+// Get the cell a point falls in on an equirectangular map
+// Longitudes outside ±180 are wrapped, and points on the poles or the antimeridian are kept
+// on the map instead of falling off the edge
+func mapCell(point geoPoint, mapW, mapH int) (int, int) {
+	lon := math.Mod(point.lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	lat := math.Max(-90, math.Min(90, point.lat))
+
+	col := int(lon / 360 * float64(mapW))
+	row := int((90 - lat) / 180 * float64(mapH))
+
+	return clampInt(col, 0, mapW-1), clampInt(row, 0, mapH-1)
+}
+
+// Keep a value between min and max
+func clampInt(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+
+	return value
+}
+
+// Work out which dots of a width by height map are land
+func landMask(width, height int) []bool {
+	land := make([]bool, width*height)
+	for row := 0; row < height; row++ {
+		lat := 90 - (float64(row)+0.5)*180/float64(height)
+		for col := 0; col < width; col++ {
+			lon := -180 + (float64(col)+0.5)*360/float64(width)
+			land[row*width+col] = isLand(lon, lat)
+		}
+	}
+
+	return land
+}
+
+// Check if a point is on land using the even-odd rule over every outline, so the seas
+// inside Eurasia cut holes in it
+func isLand(lon, lat float64) bool {
+	inside := false
+	for _, outline := range landOutlines {
+		j := len(outline) - 1
+		for i := range outline {
+			xi, yi := outline[i][0], outline[i][1]
+			xj, yj := outline[j][0], outline[j][1]
+			if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+				inside = !inside
+			}
+			j = i
+		}
+	}
+
+	return inside
+}
+
+// Rough coastlines as longitude, latitude pairs, just detailed enough to find your way around
+var landOutlines = [][][2]float64{
+	// North America
+	{
+		{-168, 66}, {-162, 70}, {-156, 71.5}, {-140, 69.5}, {-128, 70}, {-115, 68}, {-95, 72}, {-82, 69},
+		{-80, 63}, {-94, 59}, {-92, 57}, {-82, 55}, {-79, 52}, {-78, 58}, {-77, 62}, {-70, 61},
+		{-65, 60}, {-61, 56}, {-56, 52}, {-60, 48}, {-66, 45}, {-70, 43}, {-70, 41.5}, {-74, 40.5},
+		{-76, 37}, {-75.5, 35}, {-81, 31}, {-80, 25.5}, {-82, 27}, {-84, 30}, {-89, 30}, {-94, 29.5},
+		{-97, 27}, {-97.5, 22}, {-95, 18.5}, {-91, 19}, {-90, 21.5}, {-87, 21.5}, {-88, 16}, {-84, 15},
+		{-83.5, 11}, {-81, 9}, {-77.5, 8.5}, {-80, 7.5}, {-85, 10}, {-87.5, 13}, {-92, 14.5}, {-96, 15.7},
+		{-105, 19.5}, {-105.5, 23}, {-109, 26}, {-112.5, 30}, {-114.8, 31.8}, {-112, 28}, {-110, 23}, {-112, 25},
+		{-114.5, 28}, {-117, 32.5}, {-120.5, 34.5}, {-124, 40.5}, {-124, 46}, {-123, 48.5}, {-127, 50.5}, {-130, 54.5},
+		{-135, 58}, {-140, 60}, {-146, 60.5}, {-152, 58.5}, {-158, 56}, {-164, 54.5}, {-158, 58}, {-162, 60},
+		{-165, 62.5},
+	},
+	// Baffin Island
+	{
+		{-80, 73}, {-68, 70.5}, {-62, 66.5}, {-65, 63}, {-72, 62.5}, {-78, 64.5}, {-88, 70}, {-85, 73.5},
+	},
+	// Arctic Archipelago
+	{
+		{-120, 71.5}, {-105, 73}, {-90, 74}, {-80, 76}, {-75, 80}, {-62, 82.5}, {-90, 81.5}, {-110, 78.5},
+		{-122, 76}, {-125, 72},
+	},
+	// Greenland
+	{
+		{-73, 78}, {-60, 82}, {-30, 83.5}, {-20, 81.5}, {-18, 77}, {-22, 70}, {-32, 68}, {-40, 65},
+		{-43, 60}, {-48, 61}, {-53, 66}, {-54, 70}, {-58, 75.5}, {-68, 77},
+	},
+	// Iceland
+	{
+		{-24, 65.5}, {-22, 66.5}, {-15, 66.5}, {-13.5, 65}, {-18, 63.4}, {-22, 63.8},
+	},
+	// Cuba
+	{
+		{-85, 21.9}, {-81, 23.1}, {-77.5, 21.8}, {-74.1, 20.2}, {-77.7, 19.9}, {-80, 21.8}, {-82.5, 22.2},
+	},
+	// Hispaniola
+	{
+		{-74.4, 18.3}, {-72.8, 19.9}, {-69.9, 19.6}, {-68.3, 18.6}, {-71.2, 17.6},
+	},
+	// South America
+	{
+		{-77.5, 8.5}, {-76, 9.5}, {-72, 12}, {-67, 10.7}, {-62, 10.5}, {-60, 8}, {-57, 6}, {-52, 5},
+		{-50, 1.5}, {-48, -1}, {-44, -2.5}, {-39, -3.5}, {-35, -5.5}, {-35, -9}, {-38.5, -13}, {-39, -18},
+		{-41, -22}, {-44, -23}, {-48.5, -26}, {-48.5, -28.5}, {-53, -33.5}, {-57, -35}, {-57.5, -38}, {-62, -39},
+		{-65, -41}, {-64, -43}, {-67.5, -46}, {-65.8, -48}, {-69, -51}, {-68.5, -53}, {-67, -55}, {-71, -54.5},
+		{-74.5, -52}, {-75.5, -47}, {-73.5, -42}, {-73.5, -37}, {-71.5, -32}, {-71.3, -28}, {-70.3, -18.5}, {-75.5, -15},
+		{-79, -8}, {-81.2, -5.5}, {-80.3, -1.5}, {-80, 1}, {-78.8, 1.8}, {-77.5, 4}, {-77.3, 7},
+	},
+	// Africa
+	{
+		{-17, 21}, {-13, 27.5}, {-9.5, 30.5}, {-6, 35.8}, {-2, 35.1}, {3, 36.8}, {10, 37.3}, {11, 33.5},
+		{15, 32.3}, {19.5, 30.3}, {20, 32.2}, {23, 32.7}, {29, 30.9}, {32.3, 31.3}, {34, 27.8}, {37, 22},
+		{38.5, 18}, {43, 12.7}, {44, 10.5}, {51, 11.8}, {51, 10.4}, {48, 4.5}, {41.5, -1.7}, {39.2, -6.5},
+		{40.5, -10.5}, {40.5, -15}, {35, -20.5}, {35.5, -24}, {32.5, -28.5}, {27.5, -33.5}, {20, -34.8}, {18.3, -33},
+		{17.5, -29}, {15.3, -27}, {14.5, -22.5}, {11.8, -17.2}, {13.6, -11.5}, {12.2, -6}, {9, -0.8}, {9.5, 4},
+		{8.5, 4.5}, {4.5, 6.3}, {-2, 4.8}, {-7.5, 4.4}, {-11.5, 6.9}, {-13.5, 9.5}, {-16.8, 12.5}, {-17.5, 14.7},
+	},
+	// Madagascar
+	{
+		{49.3, -12}, {50.5, -15.5}, {47, -25}, {45, -25.3}, {43.5, -22}, {44.3, -16.3},
+	},
+	// Eurasia
+	{
+		{-9, 43}, {-9.5, 39}, {-9, 37}, {-6, 36.2}, {-2, 36.7}, {0.5, 38.7}, {3.2, 42}, {4.5, 43.4},
+		{7.5, 43.7}, {10, 44}, {12.3, 41.7}, {15.7, 40}, {16, 38}, {17, 39}, {18.5, 40.2}, {16, 41.5},
+		{12.3, 44.5}, {13.6, 45.7}, {15, 44.7}, {19.5, 41.8}, {20, 39.5}, {22.5, 36.5}, {23.8, 38}, {22.7, 40.5},
+		{26, 40.8}, {26.2, 39.5}, {27.3, 37}, {30.5, 36.3}, {36, 36.8}, {35.9, 35}, {34.5, 31.5}, {34.9, 29.5},
+		{36.5, 26}, {39, 21.5}, {42.8, 14.8}, {43.5, 12.7}, {45, 12.9}, {52, 15.8}, {55, 17.2}, {57.8, 19},
+		{59.8, 22.5}, {56.4, 26.3}, {56, 24.2}, {51.5, 24.2}, {51.5, 26}, {50, 26.5}, {48, 29.5}, {50, 30},
+		{51.5, 27.8}, {56.5, 27.1}, {61.5, 25.2}, {66.5, 25.3}, {68.5, 23.5}, {70.5, 20.8}, {72.8, 19.5}, {73.5, 16},
+		{74.8, 12.8}, {76.3, 9.5}, {77.5, 8}, {78.2, 8.9}, {79.8, 10.3}, {80.3, 13.5}, {80.2, 15.7}, {82.3, 17},
+		{86.5, 20}, {87, 21.6}, {91.8, 22.4}, {92.3, 20.6}, {94.3, 16}, {94.5, 18.7}, {97.7, 16.5}, {98.5, 13},
+		{98.6, 8.3}, {100.3, 5.5}, {101.3, 2.9}, {103.5, 1.3}, {104.2, 1.4}, {103.4, 4.8}, {102.2, 6.2}, {100.4, 7.5},
+		{99.5, 10.5}, {100, 13.5}, {101, 12.7}, {102.6, 12.2}, {105, 8.6}, {106.8, 10.4}, {109.2, 11.6}, {109.3, 13.4},
+		{108.5, 15.5}, {106.5, 18}, {105.7, 18.9}, {106.7, 20.7}, {108.5, 21.6}, {110.4, 20.3}, {111, 21.5}, {113.5, 22.2},
+		{117, 23.2}, {119.5, 25.5}, {121.9, 30.8}, {120.6, 33.5}, {119.2, 35}, {122.5, 37}, {121, 37.8}, {118.8, 37.2},
+		{118, 38.5}, {121.5, 40.8}, {124.4, 39.8}, {126.2, 37.7}, {126.5, 34.5}, {129.3, 35.3}, {129.5, 37}, {128.4, 38.7},
+		{129.7, 41}, {130.6, 42.4}, {135.2, 43.5}, {138.5, 46.8}, {140.4, 50.5}, {140.6, 53.2}, {137.7, 54}, {135.3, 54.8},
+		{141.4, 58.6}, {146, 59.2}, {151.2, 59.2}, {155, 59.2}, {156.8, 61.6}, {163, 62}, {160, 59.5}, {156.7, 57},
+		{155.7, 54.5}, {156.8, 51}, {158.6, 52.9}, {162.1, 56.2}, {163.3, 58.1}, {170.3, 60}, {173.7, 61.7}, {179, 62.6},
+		{180, 64.8}, {180, 69}, {175, 69.8}, {170, 70}, {160, 69.7}, {152, 70.9}, {140, 72.5}, {130, 71},
+		{127, 73.5}, {113, 73.7}, {110, 76.8}, {104, 77.7}, {100, 76.4}, {88, 75.3}, {80.5, 73.6}, {80.5, 72},
+		{72.8, 72.2}, {72.5, 68.5}, {69, 68.9}, {66.9, 69.5}, {68, 71.3}, {69, 73}, {66, 70.7}, {60.5, 69.9},
+		{56, 68.5}, {53.7, 68.9}, {46, 67.8}, {44, 68.4}, {43.5, 66.4}, {41, 67.2}, {33, 69.3}, {29, 70.8},
+		{23, 71}, {15.5, 68.5}, {12, 65.5}, {8.6, 63.4}, {5, 62}, {5.3, 59.3}, {7, 58}, {10.5, 59.2},
+		{11, 58.7}, {12.9, 55.4}, {14.3, 55.6}, {16.5, 56.5}, {18.5, 59.5}, {17.5, 61}, {17.8, 62.5}, {21.3, 64.3},
+		{22.5, 65.8}, {25.4, 65.1}, {24.5, 64.8}, {21.5, 62.5}, {21.5, 60.8}, {23, 59.9}, {28, 60.5}, {30, 59.9},
+		{28, 59.5}, {23.5, 59.2}, {23.4, 58.3}, {24.4, 57.3}, {21.2, 56.8}, {21, 55.8}, {19.6, 54.4}, {14.2, 53.9},
+		{11.2, 54.2}, {10.9, 56.4}, {10.6, 57.7}, {8.3, 56.5}, {8.6, 53.6}, {5, 53.4}, {4, 51.7}, {1.6, 51},
+		{0, 49.7}, {-1.5, 49.7}, {-1.9, 48.7}, {-4.7, 48.5}, {-4.3, 47.8}, {-2.5, 47.3}, {-1.2, 46}, {-1.6, 43.4},
+		{-4.5, 43.4}, {-8, 43.7},
+	},
+	// Chukotka, east of the antimeridian
+	{
+		{-180, 64.8}, {-177, 65.5}, {-173, 64.3}, {-169.8, 66}, {-172, 67}, {-176, 67.8}, {-180, 69},
+	},
+	// Black Sea, a hole in Eurasia
+	{
+		{28, 41.2}, {28.5, 43.5}, {29.7, 45.3}, {31.5, 46.6}, {33.5, 46}, {35.5, 45.3}, {38, 47}, {39.5, 47},
+		{38, 45}, {37.5, 44.5}, {40, 43.2}, {41.6, 41.6}, {38, 40.9}, {35, 42}, {31.5, 41.2},
+	},
+	// Caspian Sea, a hole in Eurasia
+	{
+		{47, 44.5}, {49.5, 46.5}, {53, 46.8}, {53, 45}, {51.3, 44.5}, {52.8, 41.5}, {54, 40.5}, {53.9, 37.3},
+		{51, 36.7}, {49, 37.6}, {49.5, 40.3}, {47.5, 42.8},
+	},
+	// Great Britain
+	{
+		{-5.7, 50}, {1.5, 51.2}, {1.7, 52.7}, {0, 53.5}, {-1.6, 55.6}, {-2, 57.7}, {-3.3, 58.6}, {-5, 58.6},
+		{-6.2, 56.7}, {-5, 55}, {-3, 54.9}, {-3.2, 53.4}, {-4.7, 52.8}, {-5.2, 51.7}, {-3.5, 51.4},
+	},
+	// Ireland
+	{
+		{-6, 52.2}, {-6.2, 54}, {-7.3, 55.3}, {-8.5, 54.6}, {-10, 53.5}, {-10.3, 51.8}, {-8.3, 51.6},
+	},
+	// Sicily
+	{
+		{12.4, 38}, {15.6, 38.3}, {15.1, 36.7},
+	},
+	// Sardinia and Corsica
+	{
+		{8.4, 41}, {9.6, 43}, {9.8, 39}, {8.4, 38.9},
+	},
+	// Svalbard
+	{
+		{11, 78.5}, {17, 76.5}, {25, 77.5}, {27, 79.5}, {20, 80.5}, {12, 79.8},
+	},
+	// Novaya Zemlya
+	{
+		{51.5, 71.5}, {55, 70.6}, {58, 73}, {69, 76.8}, {63, 76.3}, {55, 73.5},
+	},
+	// Sri Lanka
+	{
+		{79.8, 9.8}, {81.9, 7.3}, {81.3, 6.2}, {80.1, 6},
+	},
+	// Japan
+	{
+		{130, 31.2}, {131.5, 31.4}, {132, 33.5}, {135.2, 33.8}, {136.9, 34.3}, {139.8, 34.9}, {140.9, 36}, {141.6, 38.3},
+		{142, 40}, {141.4, 41.4}, {143.2, 41.9}, {145.5, 43.3}, {143.9, 44.1}, {141.9, 45.5}, {141.3, 43.2}, {140, 42.5},
+		{140, 40.5}, {139.8, 39}, {138.5, 37.6}, {137, 36.8}, {136, 35.6}, {133, 35.5}, {131, 34.4}, {129.8, 33.3},
+		{130.2, 31.8},
+	},
+	// Sakhalin
+	{
+		{142, 46}, {143.5, 46.8}, {143, 49.2}, {144.7, 49}, {143, 53.2}, {142.5, 54.3}, {141.7, 52.8}, {142, 49},
+	},
+	// Taiwan
+	{
+		{120.1, 23}, {121, 22}, {122, 25}, {121.5, 25.3},
+	},
+	// Hainan
+	{
+		{108.7, 19.2}, {110, 20.1}, {111, 19.6}, {109.6, 18.2},
+	},
+	// Luzon
+	{
+		{120, 18.5}, {122.3, 18.5}, {122, 16}, {124, 13}, {121, 13.7}, {120.5, 14.5}, {119.8, 16.2},
+	},
+	// Visayas
+	{
+		{121.9, 11.9}, {125.2, 12.5}, {125.3, 10}, {123, 9.2},
+	},
+	// Mindanao
+	{
+		{122, 7}, {126.5, 6.3}, {126.3, 9.3}, {123.5, 8.6},
+	},
+	// Borneo
+	{
+		{109, 1.5}, {109.7, -1.5}, {110.2, -3}, {114.5, -4}, {116.5, -3}, {116.5, 0}, {117.8, 1}, {119, 5},
+		{117.7, 6.5}, {116, 6.9}, {115.4, 4.9}, {113, 3.2}, {111, 1.5},
+	},
+	// Sumatra
+	{
+		{95.3, 5.6}, {97.5, 5.2}, {100.4, 2.3}, {103.7, -1}, {106, -3.1}, {106, -5.9}, {104.5, -5.9}, {102, -4},
+		{100.3, -1}, {98.7, 1.8},
+	},
+	// Java
+	{
+		{105.2, -6.8}, {108, -6}, {111, -6.5}, {114.5, -7.7}, {114.4, -8.7}, {110, -8.1}, {106.4, -7.4},
+	},
+	// Sulawesi
+	{
+		{119.3, -5.5}, {120.4, -5.5}, {121, -2.5}, {123.3, -4.8}, {122, -1}, {123, -0.9}, {124.9, 1.5}, {120.5, 0.8},
+		{119.7, -1}, {119, -3.5},
+	},
+	// New Guinea
+	{
+		{131, -1}, {134, -0.9}, {135.5, -3.3}, {138, -1.7}, {141, -2.6}, {145.8, -5}, {147.5, -6.2}, {148.2, -8.2},
+		{150.8, -10.5}, {147.8, -10.1}, {146, -8}, {143.3, -9}, {141, -9.1}, {138.8, -8.3}, {137.8, -5.2}, {135, -4.3},
+		{132.7, -4.1}, {131.9, -2.8},
+	},
+	// Australia
+	{
+		{113.3, -22}, {114.2, -26.3}, {115, -29.5}, {115, -34}, {118, -35}, {123.5, -33.9}, {126, -32.3}, {131, -31.5},
+		{134.2, -32.7}, {137.8, -32.6}, {135.9, -34.8}, {138, -35.6}, {140.6, -38}, {144.5, -38.3}, {146.4, -39}, {150, -37.5},
+		{151.3, -33.9}, {153.6, -28.5}, {153, -25}, {150.7, -22.5}, {146.3, -19}, {145.4, -15}, {143.5, -14}, {142.5, -10.7},
+		{141.5, -13.7}, {141.6, -17}, {139.3, -17.4}, {136, -15.5}, {136.9, -12.3}, {132.6, -11.5}, {130.2, -12.9}, {129.3, -15},
+		{126.1, -14.1}, {122.3, -17.3}, {121, -19.6}, {117, -20.7},
+	},
+	// Tasmania
+	{
+		{144.6, -40.7}, {148.3, -40.9}, {148, -43.3}, {146, -43.6},
+	},
+	// New Zealand's North Island
+	{
+		{172.7, -34.5}, {174.5, -35.5}, {178.5, -37.7}, {177.9, -39.2}, {176.9, -39.5}, {175.2, -41.6}, {174.6, -41.2}, {174, -39.6},
+		{173.8, -39.1}, {174.6, -38.5}, {174.5, -37},
+	},
+	// New Zealand's South Island
+	{
+		{172.7, -40.5}, {174.2, -41.3}, {173.8, -42.3}, {172.8, -43.5}, {171.2, -44.5}, {170.6, -45.9}, {168.3, -46.6}, {166.5, -45.9},
+		{168.3, -44}, {171.3, -41.6},
+	},
+	// Antarctica
+	{
+		{-180, -78.5}, {-150, -77}, {-140, -75}, {-120, -74}, {-100, -73}, {-80, -73}, {-70, -70}, {-62, -64.5},
+		{-58, -63.5}, {-62, -70}, {-62, -75}, {-50, -78}, {-35, -78}, {-25, -76}, {-15, -72}, {0, -70},
+		{20, -70}, {40, -69}, {55, -66.5}, {70, -67.5}, {80, -67}, {90, -66.5}, {110, -66}, {130, -66.5},
+		{145, -67}, {160, -70}, {170, -72}, {165, -78}, {180, -78.5}, {180, -90}, {-180, -90},
+	},
+}